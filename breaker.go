@@ -0,0 +1,269 @@
+package registrar
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultFailureThreshold is the number of consecutive failures that opens a circuit when no threshold is set.
+	DefaultFailureThreshold = 5
+	// DefaultCooldown is how long a circuit stays open when no cool-down is set.
+	DefaultCooldown = 30 * time.Second
+)
+
+// ErrCircuitOpen is recorded for a driver that was not called because its circuit is open.
+var ErrCircuitOpen = errors.New("driver circuit is open")
+
+// BreakerState is the state of a driver's circuit.
+type BreakerState int
+
+const (
+	// BreakerClosed lets every call through.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects calls until the cool-down has passed.
+	BreakerOpen
+	// BreakerHalfOpen lets a single trial call through. Its outcome closes or re-opens the circuit.
+	BreakerHalfOpen
+)
+
+// String returns the name of the state.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerOption for setting optional Breakers values.
+type BreakerOption func(*Breakers)
+
+// Breakers holds a circuit breaker per driver, keyed by driver name and protocol, case insensitively.
+// A circuit opens after a number of consecutive failures and rejects calls for a cool-down.
+// After the cool-down a single trial call is allowed: success closes the circuit, failure re-opens it.
+type Breakers struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+	circuits  map[driverKey]*circuit
+}
+
+// BreakerStatus describes the circuit of a single driver, for diagnostics.
+type BreakerStatus struct {
+	Name     string
+	Protocol string
+	State    BreakerState
+	// Failures is the number of consecutive failures.
+	Failures int
+	// OpenedAt is when the circuit last opened.
+	OpenedAt time.Time
+}
+
+// circuit holds the state of a single driver's circuit.
+type circuit struct {
+	// name and protocol are those of the driver the circuit was created for, as reported by Status.
+	name     string
+	protocol string
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	// trial is true while the half-open trial call is in flight.
+	trial bool
+}
+
+// WithFailureThreshold sets the number of consecutive failures that opens a circuit.
+func WithFailureThreshold(n int) BreakerOption {
+	return func(args *Breakers) { args.threshold = n }
+}
+
+// WithCooldown sets how long a circuit stays open before a trial call is allowed.
+func WithCooldown(cooldown time.Duration) BreakerOption {
+	return func(args *Breakers) { args.cooldown = cooldown }
+}
+
+// NewBreakers returns a new set of driver circuit breakers.
+func NewBreakers(opts ...BreakerOption) *Breakers {
+	defaultBreakers := &Breakers{
+		threshold: DefaultFailureThreshold,
+		cooldown:  DefaultCooldown,
+		now:       time.Now,
+		circuits:  make(map[driverKey]*circuit),
+	}
+	for _, opt := range opts {
+		opt(defaultBreakers)
+	}
+
+	return defaultBreakers
+}
+
+// Allow reports whether a driver may be called. Calling it for a driver whose
+// cool-down has passed moves the circuit to half-open and claims the trial call,
+// so the caller must report the outcome with Success, Failure or Record.
+func (b *Breakers) Allow(reg *Driver) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuit(reg)
+	switch b.state(c) {
+	case BreakerClosed:
+		return true
+	case BreakerHalfOpen:
+		if c.trial {
+			return false
+		}
+		c.state = BreakerHalfOpen
+		c.trial = true
+		return true
+	case BreakerOpen:
+		return false
+	default:
+		return false
+	}
+}
+
+// Success records a successful call, closing the driver's circuit.
+func (b *Breakers) Success(reg *Driver) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuit(reg)
+	c.state = BreakerClosed
+	c.failures = 0
+	c.trial = false
+}
+
+// Failure records a failed call. The circuit opens when the threshold is reached or a trial call fails.
+func (b *Breakers) Failure(reg *Driver) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuit(reg)
+	c.failures++
+	if c.state == BreakerHalfOpen || c.failures >= b.threshold {
+		c.state = BreakerOpen
+		c.openedAt = b.now()
+	}
+	c.trial = false
+}
+
+// Record records the outcome of a call. A nil err is a success.
+func (b *Breakers) Record(reg *Driver, err error) {
+	if err != nil {
+		b.Failure(reg)
+		return
+	}
+	b.Success(reg)
+}
+
+// release gives up a half-open trial claimed by Allow without recording an outcome,
+// for calls that were canceled by the caller rather than failed by the driver.
+func (b *Breakers) release(reg *Driver) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c, ok := b.circuits[breakerKey(reg)]; ok {
+		c.trial = false
+	}
+}
+
+// State returns the state of a driver's circuit. An open circuit whose cool-down
+// has passed is reported as half-open.
+func (b *Breakers) State(reg *Driver) BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[breakerKey(reg)]
+	if !ok {
+		return BreakerClosed
+	}
+	return b.state(c)
+}
+
+// Reset closes a driver's circuit and clears its failures.
+func (b *Breakers) Reset(reg *Driver) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.circuits, breakerKey(reg))
+}
+
+// Status returns the circuit of every driver that has recorded an outcome, sorted by name and protocol.
+func (b *Breakers) Status() []BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := make([]BreakerStatus, 0, len(b.circuits))
+	for _, c := range b.circuits {
+		status = append(status, BreakerStatus{
+			Name:     c.name,
+			Protocol: c.protocol,
+			State:    b.state(c),
+			Failures: c.failures,
+			OpenedAt: c.openedAt,
+		})
+	}
+	sort.Slice(status, func(i, j int) bool {
+		if status[i].Name != status[j].Name {
+			return status[i].Name < status[j].Name
+		}
+		return status[i].Protocol < status[j].Protocol
+	})
+	return status
+}
+
+// circuit returns the circuit of a driver, creating a closed one if needed. b.mu must be held.
+func (b *Breakers) circuit(reg *Driver) *circuit {
+	key := breakerKey(reg)
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{name: reg.Name, protocol: reg.Protocol}
+		b.circuits[key] = c
+	}
+	return c
+}
+
+// breakerKey returns the key of a driver's circuit. Names and protocols are matched case insensitively.
+func breakerKey(reg *Driver) driverKey {
+	return driverKey{name: strings.ToLower(reg.Name), protocol: strings.ToLower(reg.Protocol)}
+}
+
+// state returns the effective state of a circuit, taking the cool-down into account. b.mu must be held.
+func (b *Breakers) state(c *circuit) BreakerState {
+	if c.state == BreakerOpen && !b.now().Before(c.openedAt.Add(b.cooldown)) {
+		return BreakerHalfOpen
+	}
+	return c.state
+}
+
+// SkipOpen returns the drivers whose circuit is not open. Order is preserved.
+func (d Drivers) SkipOpen(breakers *Breakers) Drivers {
+	return d.Filter(func(reg *Driver) bool { return breakers.State(reg) != BreakerOpen })
+}
+
+// DemoteOpen returns the drivers with those whose circuit is open moved to the end.
+func (d Drivers) DemoteOpen(breakers *Breakers) Drivers {
+	return d.Rank(func(reg *Driver) float64 {
+		if breakers.State(reg) == BreakerOpen {
+			return -1
+		}
+		return 0
+	})
+}
+
+// SkipOpen does the actual work of filtering out drivers whose circuit is open.
+func (r *Registry) SkipOpen(breakers *Breakers) Drivers {
+	drivers := r.active()
+	supportedRegistries := drivers.SkipOpen(breakers)
+	r.logFiltered("SkipOpen", nil, drivers, supportedRegistries)
+	return supportedRegistries
+}
+
+// DemoteOpen does the actual work of moving drivers whose circuit is open to the end.
+func (r *Registry) DemoteOpen(breakers *Breakers) Drivers {
+	final := r.active().DemoteOpen(breakers)
+	r.logOrdered("DemoteOpen", nil, final)
+	return final
+}
@@ -0,0 +1,124 @@
+package registrar
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBreakers(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	b := NewBreakers(WithFailureThreshold(2), WithCooldown(time.Minute))
+	b.now = clock.Now
+	d := &Driver{Name: "dell", Protocol: "web"}
+
+	steps := []struct {
+		name      string
+		do        func()
+		wantState BreakerState
+		wantAllow bool
+	}{
+		{name: "new circuit is closed", do: func() {}, wantState: BreakerClosed, wantAllow: true},
+		{name: "one failure stays closed", do: func() { b.Failure(d) }, wantState: BreakerClosed, wantAllow: true},
+		{name: "success resets failures", do: func() { b.Success(d); b.Failure(d) }, wantState: BreakerClosed, wantAllow: true},
+		{name: "threshold opens", do: func() { b.Failure(d) }, wantState: BreakerOpen, wantAllow: false},
+		{name: "cool-down passes", do: func() { clock.now = clock.now.Add(time.Minute) }, wantState: BreakerHalfOpen, wantAllow: true},
+		{name: "only one trial call", do: func() {}, wantState: BreakerHalfOpen, wantAllow: false},
+		{name: "failed trial re-opens", do: func() { b.Failure(d) }, wantState: BreakerOpen, wantAllow: false},
+		{name: "second cool-down", do: func() { clock.now = clock.now.Add(time.Minute) }, wantState: BreakerHalfOpen, wantAllow: true},
+		{name: "successful trial closes", do: func() { b.Success(d) }, wantState: BreakerClosed, wantAllow: true},
+	}
+	for _, step := range steps {
+		step.do()
+		if got := b.State(d); got != step.wantState {
+			t.Fatalf("%s: expected state %v, got %v", step.name, step.wantState, got)
+		}
+		if got := b.Allow(d); got != step.wantAllow {
+			t.Fatalf("%s: expected allow %v, got %v", step.name, step.wantAllow, got)
+		}
+	}
+
+	b.Record(d, errDriverFailed)
+	b.Record(d, errDriverFailed)
+	want := []BreakerStatus{{Name: "dell", Protocol: "web", State: BreakerOpen, Failures: 2, OpenedAt: clock.now}}
+	if diff := cmp.Diff(b.Status(), want); diff != "" {
+		t.Fatal(diff)
+	}
+	b.Reset(d)
+	if got := b.State(d); got != BreakerClosed {
+		t.Fatalf("expected reset circuit to be closed, got %v", got)
+	}
+}
+
+func TestBreakersCaseInsensitive(t *testing.T) {
+	b := NewBreakers(WithFailureThreshold(2))
+	upper := &Driver{Name: "Redfish", Protocol: "Redfish"}
+	lower := &Driver{Name: "redfish", Protocol: "redfish"}
+
+	b.Failure(upper)
+	b.Failure(lower)
+	if got := b.State(lower); got != BreakerOpen {
+		t.Fatalf("expected drivers differing only in case to share a circuit, got %v", got)
+	}
+	want := []BreakerStatus{{Name: "Redfish", Protocol: "Redfish", State: BreakerOpen, Failures: 2, OpenedAt: b.Status()[0].OpenedAt}}
+	if diff := cmp.Diff(b.Status(), want); diff != "" {
+		t.Fatal(diff)
+	}
+	b.Reset(lower)
+	if got := b.State(upper); got != BreakerClosed {
+		t.Fatalf("expected reset circuit to be closed, got %v", got)
+	}
+}
+
+func TestBreakersFiltering(t *testing.T) {
+	b := NewBreakers(WithFailureThreshold(1))
+	rg := NewRegistry()
+	rg.Register("webui", "web", nil, nil, nil)
+	rg.Register("ipmitool", "ipmi", nil, nil, nil)
+	rg.Register("redfish", "redfish", nil, nil, nil)
+	b.Failure(rg.For("webui")[0])
+
+	if diff := cmp.Diff(rg.SkipOpen(b).Names(), []string{"ipmitool", "redfish"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if diff := cmp.Diff(rg.DemoteOpen(b).Names(), []string{"ipmitool", "redfish", "webui"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if diff := cmp.Diff(rg.Query().PreferDriver("webui", "redfish").DemoteOpen(b).Drivers().Names(), []string{"redfish", "ipmitool", "webui"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if diff := cmp.Diff(rg.Query().SkipOpen(b).Drivers().Names(), []string{"ipmitool", "redfish"}); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestFirstSuccessWithBreakers(t *testing.T) {
+	b := NewBreakers(WithFailureThreshold(1))
+	drivers := Drivers{{Name: "webui", Protocol: "web"}, {Name: "ipmitool", Protocol: "ipmi"}}
+	var calls []string
+	fn := func(_ context.Context, d *Driver) error {
+		calls = append(calls, d.Name)
+		if d.Name == "webui" {
+			return errDriverFailed
+		}
+		return nil
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := FirstSuccess(context.Background(), drivers, fn, WithBreakers(b)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the failing driver is only hit on the first attempt.
+	if diff := cmp.Diff(calls, []string{"webui", "ipmitool", "ipmitool"}); diff != "" {
+		t.Fatal(diff)
+	}
+
+	_, err := FirstSuccess(context.Background(), drivers[:1], fn, WithBreakers(b))
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected %v, got %v", ErrCircuitOpen, err)
+	}
+}
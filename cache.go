@@ -0,0 +1,175 @@
+package registrar
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL is how long a CompatibilityCache keeps results when no TTL is set.
+const DefaultCacheTTL = 5 * time.Minute
+
+// CacheOption for setting optional CompatibilityCache values.
+type CacheOption func(*CompatibilityCache)
+
+// CompatibilityCache caches driver compatibility results per target.
+// A target is a caller supplied key, for example the host a registry is used against.
+// Results are keyed by target and driver name and protocol, and are only reused for the
+// implementation they were checked with. Timed out checks are never cached.
+type CompatibilityCache struct {
+	mu          sync.Mutex
+	ttl         time.Duration
+	negativeTTL time.Duration
+	now         func() time.Time
+	entries     map[driverKey]cacheEntry
+	hits        uint64
+	misses      uint64
+}
+
+// CacheStats holds the usage statistics of a CompatibilityCache.
+type CacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+}
+
+// driverKey identifies a driver, by name and protocol, for a target.
+type driverKey struct {
+	target   string
+	name     string
+	protocol string
+}
+
+// cacheEntry is a cached compatibility result and when it expires.
+type cacheEntry struct {
+	result  CompatibilityResult
+	expires time.Time
+}
+
+// WithCacheTTL sets how long compatible results are cached.
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(args *CompatibilityCache) { args.ttl = ttl }
+}
+
+// WithNegativeCacheTTL sets how long incompatible results are cached.
+// It defaults to the TTL of compatible results. A value of 0 disables caching of incompatible results.
+func WithNegativeCacheTTL(ttl time.Duration) CacheOption {
+	return func(args *CompatibilityCache) { args.negativeTTL = ttl }
+}
+
+// NewCompatibilityCache returns a new compatibility result cache.
+func NewCompatibilityCache(opts ...CacheOption) *CompatibilityCache {
+	defaultCache := &CompatibilityCache{
+		ttl:         DefaultCacheTTL,
+		negativeTTL: -1,
+		now:         time.Now,
+		entries:     make(map[driverKey]cacheEntry),
+	}
+	for _, opt := range opts {
+		opt(defaultCache)
+	}
+	if defaultCache.negativeTTL < 0 {
+		defaultCache.negativeTTL = defaultCache.ttl
+	}
+
+	return defaultCache
+}
+
+// Get returns the cached compatibility result of a driver for a target.
+// The returned result refers to the given driver and is marked as Cached.
+// A result cached for a different implementation of the driver, for example
+// before a Replace or from an overridden parent driver, is not returned.
+func (c *CompatibilityCache) Get(target string, reg *Driver) (*CompatibilityResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := driverKey{target: target, name: reg.Name, protocol: reg.Protocol}
+	entry, ok := c.entries[key]
+	if ok && (!c.now().Before(entry.expires) || !sameImplementation(entry.result.Driver, reg)) {
+		delete(c.entries, key)
+		ok = false
+	}
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	res := entry.result
+	res.Driver = reg
+	res.Cached = true
+	return &res, true
+}
+
+// Set caches the compatibility result of a driver for a target.
+// Results of timed out checks are not cached.
+func (c *CompatibilityCache) Set(target string, res *CompatibilityResult) {
+	if res == nil || errors.Is(res.Err, ErrProbeTimeout) {
+		return
+	}
+	ttl := c.ttl
+	if !res.Compatible {
+		ttl = c.negativeTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := driverKey{target: target, name: res.Name, protocol: res.Protocol}
+	c.entries[key] = cacheEntry{result: *res, expires: c.now().Add(ttl)}
+}
+
+// Invalidate removes every cached result for a target.
+func (c *CompatibilityCache) Invalidate(target string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if key.target == target {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// InvalidateDriver removes the cached result of a single driver for a target.
+func (c *CompatibilityCache) InvalidateDriver(target, name, protocol string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, driverKey{target: target, name: name, protocol: protocol})
+}
+
+// Purge removes every cached result.
+func (c *CompatibilityCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[driverKey]cacheEntry)
+}
+
+// Stats returns the cache hit and miss counts and the number of cached results.
+func (c *CompatibilityCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{Hits: c.hits, Misses: c.misses, Entries: len(c.entries)}
+}
+
+// sameImplementation reports whether two drivers have the same DriverInterface. Implementations
+// that can not be compared, including comparable types holding values that can not be, are treated
+// as different, unless they belong to the same driver. Drivers cached without a Driver are treated as the same.
+func sameImplementation(cached, reg *Driver) (same bool) {
+	if cached == nil || cached == reg {
+		return true
+	}
+	a, b := cached.DriverInterface, reg.DriverInterface
+	if a == nil || b == nil {
+		return a == b
+	}
+	if reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.TypeOf(a).Comparable() {
+		return false
+	}
+	// comparing values whose dynamic type can not be compared, held in an interface field, panics.
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+	return a == b
+}
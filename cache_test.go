@@ -0,0 +1,163 @@
+package registrar
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// countingDriver is a Verifier that counts how many times it was checked.
+type countingDriver struct {
+	compatible bool
+	calls      int32
+}
+
+func (c *countingDriver) Compatible(_ context.Context) bool {
+	atomic.AddInt32(&c.calls, 1)
+	return c.compatible
+}
+
+// fakeClock is a controllable time source for cache tests.
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time { return f.now }
+
+func TestCompatibilityCache(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	cache := NewCompatibilityCache(WithCacheTTL(time.Minute), WithNegativeCacheTTL(10*time.Second))
+	cache.now = clock.Now
+
+	good := &countingDriver{compatible: true}
+	bad := &countingDriver{}
+	rg := NewRegistry()
+	rg.Register("good", "web", nil, nil, good)
+	rg.Register("bad", "web", nil, nil, bad)
+
+	filter := func(target string) Drivers {
+		return rg.FilterForCompatible(context.Background(), WithProbeCache(cache, target))
+	}
+	for i := 0; i < 3; i++ {
+		if diff := cmp.Diff(filter("host1").Names(), []string{"good"}); diff != "" {
+			t.Fatal(diff)
+		}
+	}
+	if good.calls != 1 || bad.calls != 1 {
+		t.Fatalf("expected each driver to be checked once, got good=%d bad=%d", good.calls, bad.calls)
+	}
+	if diff := cmp.Diff(cache.Stats(), CacheStats{Hits: 4, Misses: 2, Entries: 2}); diff != "" {
+		t.Fatal(diff)
+	}
+
+	// a different target is cached separately.
+	filter("host2")
+	if good.calls != 2 || bad.calls != 2 {
+		t.Fatalf("expected each driver to be checked for the new target, got good=%d bad=%d", good.calls, bad.calls)
+	}
+
+	// incompatible results expire first.
+	clock.now = clock.now.Add(30 * time.Second)
+	filter("host1")
+	if good.calls != 2 || bad.calls != 3 {
+		t.Fatalf("expected only the negative result to expire, got good=%d bad=%d", good.calls, bad.calls)
+	}
+	clock.now = clock.now.Add(time.Minute)
+	filter("host1")
+	if good.calls != 3 || bad.calls != 4 {
+		t.Fatalf("expected both results to expire, got good=%d bad=%d", good.calls, bad.calls)
+	}
+
+	cache.InvalidateDriver("host1", "good", "web")
+	filter("host1")
+	if good.calls != 4 || bad.calls != 4 {
+		t.Fatalf("expected only the invalidated driver to be checked, got good=%d bad=%d", good.calls, bad.calls)
+	}
+	cache.Invalidate("host1")
+	filter("host1")
+	if good.calls != 5 || bad.calls != 5 {
+		t.Fatalf("expected the invalidated target to be checked, got good=%d bad=%d", good.calls, bad.calls)
+	}
+	cache.Purge()
+	if got := cache.Stats().Entries; got != 0 {
+		t.Fatalf("expected an empty cache, got %d entries", got)
+	}
+}
+
+func TestCompatibilityCacheReport(t *testing.T) {
+	cache := NewCompatibilityCache(WithNegativeCacheTTL(0))
+	rg := NewRegistry()
+	rg.Register("good", "web", nil, nil, &countingDriver{compatible: true})
+	rg.Register("bad", "web", nil, nil, &countingDriver{})
+	rg.Register("hung", "web", nil, nil, &slowDriver{delay: time.Minute})
+
+	rg.FilterForCompatibleReport(context.Background(), WithProbeCache(cache, "host"), WithProbeTimeout(10*time.Millisecond))
+	_, report := rg.FilterForCompatibleReport(context.Background(), WithProbeCache(cache, "host"), WithProbeTimeout(10*time.Millisecond))
+	got := map[string]bool{}
+	for _, res := range report {
+		got[res.Name] = res.Cached
+	}
+	// incompatible results are not cached with a negative TTL of 0, timeouts are never cached.
+	if diff := cmp.Diff(got, map[string]bool{"good": true, "bad": false, "hung": false}); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestCompatibilityCacheImplementation(t *testing.T) {
+	cache := NewCompatibilityCache()
+	base := NewRegistry()
+	base.Register("gofish", "redfish", nil, nil, &countingDriver{compatible: false})
+	tenant := base.Child()
+	tenant.Register("gofish", "redfish", nil, nil, &countingDriver{compatible: true})
+
+	if got := base.FilterForCompatible(context.Background(), WithProbeCache(cache, "bmc")); len(got) != 0 {
+		t.Fatalf("expected the base driver to be incompatible, got %v", got.Names())
+	}
+	if diff := cmp.Diff(tenant.FilterForCompatible(context.Background(), WithProbeCache(cache, "bmc")).Names(), []string{"gofish"}); diff != "" {
+		t.Fatalf("expected the overriding driver not to reuse the parent's result: %v", diff)
+	}
+
+	replacement := &countingDriver{compatible: false}
+	if err := tenant.Replace("gofish", "redfish", nil, nil, replacement); err != nil {
+		t.Fatal(err)
+	}
+	if got := tenant.FilterForCompatible(context.Background(), WithProbeCache(cache, "bmc")); len(got) != 0 {
+		t.Fatalf("expected the replaced driver to be checked again, got %v", got.Names())
+	}
+	if err := tenant.Disable("gofish", "redfish"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tenant.Enable("gofish", "redfish"); err != nil {
+		t.Fatal(err)
+	}
+	tenant.FilterForCompatible(context.Background(), WithProbeCache(cache, "bmc"))
+	if calls := atomic.LoadInt32(&replacement.calls); calls != 1 {
+		t.Fatalf("expected the result to be reused for the same implementation, got %d checks", calls)
+	}
+}
+
+// boxedDriver is a comparable type that can hold a value that is not.
+type boxedDriver struct {
+	v interface{}
+}
+
+func TestCompatibilityCacheUncomparableImplementation(t *testing.T) {
+	cache := NewCompatibilityCache()
+	rg := NewRegistry()
+	rg.Register("boxed", "web", nil, nil, boxedDriver{v: []int{1}})
+	rg.FilterForCompatible(context.Background(), WithProbeCache(cache, "bmc"))
+
+	if err := rg.Replace("boxed", "web", nil, nil, boxedDriver{v: []int{2}}); err != nil {
+		t.Fatal(err)
+	}
+	got := rg.FilterForCompatible(context.Background(), WithProbeCache(cache, "bmc"))
+	if diff := cmp.Diff(got.Names(), []string{"boxed"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if stats := cache.Stats(); stats.Hits != 0 {
+		t.Fatalf("expected an implementation that can not be compared not to reuse a result, got %+v", stats)
+	}
+}
@@ -0,0 +1,361 @@
+package registrar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrProbeTimeout is recorded for a driver whose compatibility check did not finish in time.
+	ErrProbeTimeout = errors.New("compatibility check timed out")
+	// ErrIncompatible is recorded for a driver that reported itself incompatible without giving a reason.
+	ErrIncompatible = errors.New("driver reported it is not compatible")
+)
+
+// PanicError is recorded for a driver that panicked. The driver is treated as incompatible.
+type PanicError struct {
+	// Value is the value the driver panicked with.
+	Value interface{}
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("driver panicked: %v", e.Value)
+}
+
+// VerifierWithReason allows implementations to explain why they are not compatible.
+// It is used in place of Verifier when a driver implements both. A non nil error
+// means the driver is not compatible, regardless of the returned bool.
+type VerifierWithReason interface {
+	CompatibleWithReason(context.Context) (bool, error)
+}
+
+// ProbeOption for setting optional compatibility check values.
+type ProbeOption func(*probeConfig)
+
+// probeConfig holds the settings used when checking drivers for compatibility.
+type probeConfig struct {
+	// timeout bounds each individual driver's Compatible call.
+	timeout time.Duration
+	// concurrency bounds the number of Compatible calls running at once.
+	concurrency int
+	// deadline bounds the whole compatibility check.
+	deadline time.Duration
+	// cache, when set, is consulted before and updated after checking a driver.
+	cache *CompatibilityCache
+	// target is the cache key results are stored under.
+	target string
+}
+
+// CompatibilityResult holds the outcome of checking a single driver for compatibility.
+type CompatibilityResult struct {
+	Name       string
+	Protocol   string
+	Driver     *Driver
+	Compatible bool
+	Duration   time.Duration
+	// Cached is true when the result came from a CompatibilityCache.
+	Cached bool
+	// Err is the reason the driver is not compatible. It is nil for compatible drivers.
+	Err error
+}
+
+// CompatibilityReport holds the compatibility results of drivers, in driver order.
+type CompatibilityReport []*CompatibilityResult
+
+// Incompatible returns the results of the drivers that are not compatible.
+func (c CompatibilityReport) Incompatible() CompatibilityReport {
+	var results CompatibilityReport
+	for _, res := range c {
+		if !res.Compatible {
+			results = append(results, res)
+		}
+	}
+	return results
+}
+
+// drivers returns the drivers of the results.
+func (c CompatibilityReport) drivers() Drivers {
+	drivers := make(Drivers, 0, len(c))
+	for _, res := range c {
+		drivers = append(drivers, res.Driver)
+	}
+	return drivers
+}
+
+// For returns the results of every driver with the given name.
+func (c CompatibilityReport) For(name string) CompatibilityReport {
+	var results CompatibilityReport
+	for _, res := range c {
+		if res.Name == name {
+			results = append(results, res)
+		}
+	}
+	return results
+}
+
+// WithProbeTimeout sets the maximum time a single driver's Compatible call may take.
+// Drivers that exceed it are treated as incompatible.
+func WithProbeTimeout(timeout time.Duration) ProbeOption {
+	return func(args *probeConfig) { args.timeout = timeout }
+}
+
+// WithProbeConcurrency sets the maximum number of Compatible calls that run at the same time.
+// A call abandoned because of a timeout keeps its slot until it really returns. When a
+// timeout is set, see WithProbeTimeout, drivers waiting for a slot are treated as timed out
+// once no slot has been freed for that long.
+// A value less than 1 means no limit.
+func WithProbeConcurrency(n int) ProbeOption {
+	return func(args *probeConfig) { args.concurrency = n }
+}
+
+// WithProbeDeadline sets the maximum time the whole compatibility check may take.
+// Drivers still pending or not yet started when it expires are treated as incompatible.
+func WithProbeDeadline(deadline time.Duration) ProbeOption {
+	return func(args *probeConfig) { args.deadline = deadline }
+}
+
+// WithProbeCache sets a cache to reuse compatibility results from.
+// Results are stored under target, for example the host the drivers are used against.
+func WithProbeCache(cache *CompatibilityCache, target string) ProbeOption {
+	return func(args *probeConfig) {
+		args.cache = cache
+		args.target = target
+	}
+}
+
+// FilterForCompatible updates the driver registry with only compatible implementations.
+// compatible implementations are determined by running the Compatible method of the Verifier
+// interface. registered drivers must implement the Verifier interface for this. Order is preserved.
+// Drivers whose check times out, see WithProbeTimeout and WithProbeDeadline, are treated as incompatible.
+func (r *Registry) FilterForCompatible(ctx context.Context, opts ...ProbeOption) Drivers {
+	compatible, _ := r.FilterForCompatibleReport(ctx, opts...)
+	return compatible
+}
+
+// FilterForCompatibleReport does the same work as FilterForCompatible and also returns
+// the compatibility result of every driver, including why incompatible drivers were dropped.
+func (r *Registry) FilterForCompatibleReport(ctx context.Context, opts ...ProbeOption) (Drivers, CompatibilityReport) {
+	var compatible Drivers
+	report := probe(ctx, r.active(), opts...)
+	r.recordCompatibility(report)
+	for _, res := range report {
+		r.logProbe(res)
+		if res.Compatible {
+			compatible = append(compatible, res.Driver)
+			continue
+		}
+		var perr *PanicError
+		if errors.As(res.Err, &perr) {
+			r.Logger.Error(res.Err, "driver panicked during compatibility check, treating driver as incompatible", "name", res.Name, "protocol", res.Protocol, "stack", string(perr.Stack))
+		}
+		if errors.Is(res.Err, ErrProbeTimeout) {
+			r.Logger.Info("compatibility check timed out, treating driver as incompatible", "name", res.Name, "protocol", res.Protocol, "error", res.Err.Error())
+		}
+	}
+
+	r.logFiltered("FilterForCompatible", nil, report.drivers(), compatible)
+	return compatible, report
+}
+
+// probe checks every non nil driver for compatibility, concurrently.
+// The results are in the same order as the drivers.
+func probe(ctx context.Context, drivers Drivers, opts ...ProbeOption) CompatibilityReport {
+	cfg := &probeConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.deadline)
+		defer cancel()
+	}
+	var slots *probeSlots
+	if cfg.concurrency > 0 {
+		slots = newProbeSlots(cfg.concurrency)
+	}
+
+	var wg sync.WaitGroup
+	order := make(CompatibilityReport, len(drivers))
+	for idx, elem := range drivers {
+		if elem == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(reg *Driver, num int) {
+			defer wg.Done()
+			if cfg.cache != nil {
+				if res, ok := cfg.cache.Get(cfg.target, reg); ok {
+					order[num] = res
+					return
+				}
+			}
+			release := func() {}
+			if slots != nil {
+				if err := slots.acquire(ctx, cfg.timeout); err != nil {
+					order[num] = newResult(reg, false, err, 0)
+					return
+				}
+				release = slots.release
+			}
+			order[num] = probeDriver(ctx, reg, cfg.timeout, release)
+			if cfg.cache != nil {
+				cfg.cache.Set(cfg.target, order[num])
+			}
+		}(elem, idx)
+	}
+	wg.Wait()
+
+	var results CompatibilityReport
+	for _, res := range order {
+		if res != nil {
+			results = append(results, res)
+		}
+	}
+	return results
+}
+
+// probeSlots limits the number of compatibility checks running at once, see WithProbeConcurrency.
+type probeSlots struct {
+	mu   sync.Mutex
+	free int
+	// freed is closed, and replaced, every time a slot is freed.
+	freed chan struct{}
+}
+
+// newProbeSlots returns n free slots.
+func newProbeSlots(n int) *probeSlots {
+	return &probeSlots{free: n, freed: make(chan struct{})}
+}
+
+// acquire takes a slot, waiting until one is freed. When idle is set and no slot is freed for
+// that long, for example because every slot is held by an abandoned check that never returns,
+// it gives up with ErrProbeTimeout. It also gives up when ctx is done.
+func (p *probeSlots) acquire(ctx context.Context, idle time.Duration) error {
+	for {
+		p.mu.Lock()
+		if p.free > 0 {
+			p.free--
+			p.mu.Unlock()
+			return nil
+		}
+		freed := p.freed
+		p.mu.Unlock()
+
+		if err := waitFreed(ctx, freed, idle); err != nil {
+			return err
+		}
+	}
+}
+
+// waitFreed waits for freed to be closed, for ctx to be done or, when set, for idle to pass.
+func waitFreed(ctx context.Context, freed <-chan struct{}, idle time.Duration) error {
+	var expired <-chan time.Time
+	if idle > 0 {
+		t := time.NewTimer(idle)
+		defer t.Stop()
+		expired = t.C
+	}
+	select {
+	case <-freed:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %w", ErrProbeTimeout, ctx.Err())
+	case <-expired:
+		return fmt.Errorf("%w: no check slot was freed within %v", ErrProbeTimeout, idle)
+	}
+}
+
+// release frees a slot and wakes every check waiting for one.
+func (p *probeSlots) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.free++
+	close(p.freed)
+	p.freed = make(chan struct{})
+}
+
+// probeDriver runs the compatibility check of a single driver.
+// Drivers that implement neither VerifierWithReason nor Verifier are compatible.
+// A panic in the check is recovered and recorded as a *PanicError.
+// The check is abandoned, not interrupted, when ctx is done or the timeout expires.
+// release is called once the check has really returned, even when it was abandoned, so
+// abandoned checks still count against WithProbeConcurrency.
+func probeDriver(ctx context.Context, reg *Driver, timeout time.Duration, release func()) *CompatibilityResult {
+	var check func(context.Context) (bool, error)
+	switch c := reg.DriverInterface.(type) {
+	case VerifierWithReason:
+		check = c.CompatibleWithReason
+	case Verifier:
+		check = func(ctx context.Context) (bool, error) { return c.Compatible(ctx), nil }
+	default:
+		release()
+		return newResult(reg, true, nil, 0)
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	if err := ctx.Err(); err != nil {
+		release()
+		return newResult(reg, false, fmt.Errorf("%w: %w", ErrProbeTimeout, err), 0)
+	}
+
+	type outcome struct {
+		compatible bool
+		err        error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer release()
+		var o outcome
+		returned := false
+		// an outcome is always sent, a driver calling panic(nil) makes recover return nil.
+		defer func() {
+			if !returned {
+				o = outcome{err: &PanicError{Value: recover(), Stack: debug.Stack()}}
+			}
+			done <- o
+		}()
+		o.compatible, o.err = check(ctx)
+		returned = true
+	}()
+	select {
+	case o := <-done:
+		return newResult(reg, o.compatible, o.err, time.Since(start))
+	case <-ctx.Done():
+		select {
+		case o := <-done:
+			return newResult(reg, o.compatible, o.err, time.Since(start))
+		default:
+		}
+		return newResult(reg, false, fmt.Errorf("%w: %w", ErrProbeTimeout, ctx.Err()), time.Since(start))
+	}
+}
+
+// newResult builds the compatibility result for a driver.
+// A driver is only compatible when it reported so without an error.
+func newResult(reg *Driver, compatible bool, err error, duration time.Duration) *CompatibilityResult {
+	if err != nil {
+		compatible = false
+	}
+	if !compatible && err == nil {
+		err = ErrIncompatible
+	}
+	return &CompatibilityResult{
+		Name:       reg.Name,
+		Protocol:   reg.Protocol,
+		Driver:     reg,
+		Compatible: compatible,
+		Duration:   duration,
+		Err:        err,
+	}
+}
@@ -0,0 +1,258 @@
+package registrar
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+)
+
+// slowDriver is a Verifier that takes delay to answer and ignores context cancellation.
+type slowDriver struct {
+	delay    time.Duration
+	inFlight *int32
+	maxSeen  *int32
+}
+
+func (s *slowDriver) Compatible(_ context.Context) bool {
+	if s.inFlight != nil {
+		n := atomic.AddInt32(s.inFlight, 1)
+		defer atomic.AddInt32(s.inFlight, -1)
+		for {
+			seen := atomic.LoadInt32(s.maxSeen)
+			if n <= seen || atomic.CompareAndSwapInt32(s.maxSeen, seen, n) {
+				break
+			}
+		}
+	}
+	time.Sleep(s.delay)
+	return true
+}
+
+func TestFilterForCompatibleProbeTimeout(t *testing.T) {
+	rg := NewRegistry()
+	rg.Register("fast", "tcp", nil, nil, &slowDriver{})
+	rg.Register("hung", "tcp", nil, nil, &slowDriver{delay: time.Minute})
+	rg.Register("verifierless", "tcp", nil, nil, struct{}{})
+
+	start := time.Now()
+	got := rg.FilterForCompatible(context.Background(), WithProbeTimeout(50*time.Millisecond))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected hung driver to be abandoned, filtering took %v", elapsed)
+	}
+	if diff := cmp.Diff(got.Names(), []string{"fast", "verifierless"}); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestFilterForCompatibleProbeDeadline(t *testing.T) {
+	rg := NewRegistry()
+	for _, name := range []string{"one", "two", "three"} {
+		rg.Register(name, "tcp", nil, nil, &slowDriver{delay: 40 * time.Millisecond})
+	}
+
+	// with a concurrency of 1 only a single driver can finish before the deadline.
+	got := rg.FilterForCompatible(context.Background(), WithProbeConcurrency(1), WithProbeDeadline(60*time.Millisecond))
+	if len(got) != 1 {
+		t.Fatalf("expected 1 compatible driver, got %v", got.Names())
+	}
+}
+
+func TestFilterForCompatibleProbeConcurrency(t *testing.T) {
+	var inFlight, maxSeen int32
+	rg := NewRegistry()
+	for i := 0; i < 10; i++ {
+		rg.Register("driver", "tcp", nil, nil, &slowDriver{delay: 5 * time.Millisecond, inFlight: &inFlight, maxSeen: &maxSeen})
+	}
+
+	got := rg.FilterForCompatible(context.Background(), WithProbeConcurrency(3))
+	if len(got) != 10 {
+		t.Fatalf("expected 10 compatible drivers, got %d", len(got))
+	}
+	if maxSeen > 3 {
+		t.Fatalf("expected at most 3 concurrent checks, got %d", maxSeen)
+	}
+}
+
+func TestFilterForCompatibleProbeConcurrencyWithTimeout(t *testing.T) {
+	var inFlight, maxSeen int32
+	rg := NewRegistry()
+	for i := 0; i < 6; i++ {
+		rg.Register("driver", "tcp", nil, nil, &slowDriver{delay: 50 * time.Millisecond, inFlight: &inFlight, maxSeen: &maxSeen})
+	}
+
+	got := rg.FilterForCompatible(context.Background(), WithProbeConcurrency(2), WithProbeTimeout(5*time.Millisecond))
+	if len(got) != 0 {
+		t.Fatalf("expected every check to time out, got %d compatible drivers", len(got))
+	}
+	if n := atomic.LoadInt32(&maxSeen); n > 2 {
+		t.Fatalf("expected abandoned checks to keep their slot, got %d concurrent checks", n)
+	}
+}
+
+// hungDriver is a Verifier that never returns.
+type hungDriver struct{}
+
+func (hungDriver) Compatible(_ context.Context) bool {
+	select {}
+}
+
+func TestFilterForCompatibleHungDriverHoldsSlot(t *testing.T) {
+	rg := NewRegistry()
+	rg.Register("hung", "ipmi", nil, nil, hungDriver{})
+	rg.Register("hung-too", "ipmi", nil, nil, hungDriver{})
+	for i := 0; i < 3; i++ {
+		rg.Register("fast", "redfish", nil, nil, &slowDriver{delay: time.Millisecond})
+	}
+
+	done := make(chan CompatibilityReport, 1)
+	go func() {
+		_, report := rg.FilterForCompatibleReport(context.Background(), WithProbeConcurrency(1), WithProbeTimeout(20*time.Millisecond))
+		done <- report
+	}()
+	select {
+	case report := <-done:
+		if len(report) != 5 {
+			t.Fatalf("expected a result per driver, got %d", len(report))
+		}
+		for _, res := range report.Incompatible() {
+			if !errors.Is(res.Err, ErrProbeTimeout) {
+				t.Fatalf("expected %v for %v, got %v", ErrProbeTimeout, res.Name, res.Err)
+			}
+		}
+		if got := len(report.For("hung")) + len(report.For("hung-too")); got != 2 || report.For("hung")[0].Compatible || report.For("hung-too")[0].Compatible {
+			t.Fatalf("expected the hung drivers to time out, got %+v", report)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a hung driver holding the only slot not to stall the whole check")
+	}
+}
+
+var errNoIPMI = errors.New("ipmi not enabled on this host")
+
+// reasonDriver is a VerifierWithReason.
+type reasonDriver struct {
+	compatible bool
+	err        error
+}
+
+func (r *reasonDriver) CompatibleWithReason(_ context.Context) (bool, error) {
+	return r.compatible, r.err
+}
+
+// Compatible must not be used when CompatibleWithReason is implemented.
+func (r *reasonDriver) Compatible(_ context.Context) bool {
+	return true
+}
+
+func TestFilterForCompatibleReport(t *testing.T) {
+	rg := NewRegistry()
+	rg.Register("redfish", "redfish", nil, nil, &reasonDriver{compatible: true})
+	rg.Register("ipmitool", "ipmi", nil, nil, &reasonDriver{err: errNoIPMI})
+	rg.Register("dell", "web", nil, nil, &driverOne{isCompatible: false})
+	rg.Register("smc", "web", nil, nil, &reasonDriver{})
+	rg.Register("errored", "web", nil, nil, &reasonDriver{compatible: true, err: errNoIPMI})
+	rg.Register("hung", "web", nil, nil, &slowDriver{delay: time.Minute})
+
+	compatible, report := rg.FilterForCompatibleReport(context.Background(), WithProbeTimeout(20*time.Millisecond))
+	if diff := cmp.Diff(compatible.Names(), []string{"redfish"}); diff != "" {
+		t.Fatal(diff)
+	}
+	wantErrs := map[string]error{
+		"redfish":  nil,
+		"ipmitool": errNoIPMI,
+		"dell":     ErrIncompatible,
+		"smc":      ErrIncompatible,
+		"errored":  errNoIPMI,
+		"hung":     ErrProbeTimeout,
+	}
+	if len(report) != len(wantErrs) {
+		t.Fatalf("expected %d results, got %d", len(wantErrs), len(report))
+	}
+	for name, want := range wantErrs {
+		res := report.For(name)
+		if len(res) != 1 {
+			t.Fatalf("expected a single result for %v, got %v", name, res)
+		}
+		if want == nil && res[0].Err != nil {
+			t.Errorf("%v: expected no error, got %v", name, res[0].Err)
+		}
+		if !errors.Is(res[0].Err, want) {
+			t.Errorf("%v: expected error %v, got %v", name, want, res[0].Err)
+		}
+	}
+	if got := len(report.Incompatible()); got != 5 {
+		t.Fatalf("expected 5 incompatible results, got %d", got)
+	}
+	if report.For("hung")[0].Duration < 20*time.Millisecond {
+		t.Fatalf("expected hung driver duration to be at least the probe timeout, got %v", report.For("hung")[0].Duration)
+	}
+}
+
+// panicDriver is a Verifier that panics.
+type panicDriver struct{}
+
+func (panicDriver) Compatible(_ context.Context) bool {
+	panic("third-party driver bug")
+}
+
+// nilPanicDriver is a Verifier that panics with a nil value.
+type nilPanicDriver struct{}
+
+func (nilPanicDriver) Compatible(_ context.Context) bool {
+	panic(nil)
+}
+
+func TestFilterForCompatibleNilPanic(t *testing.T) {
+	rg := NewRegistry()
+	rg.Register("panics", "web", nil, nil, nilPanicDriver{})
+	rg.Register("dell", "web", nil, nil, &driverOne{isCompatible: true})
+
+	done := make(chan CompatibilityReport, 1)
+	go func() {
+		_, report := rg.FilterForCompatibleReport(context.Background())
+		done <- report
+	}()
+	select {
+	case report := <-done:
+		var perr *PanicError
+		if !errors.As(report.For("panics")[0].Err, &perr) {
+			t.Fatalf("expected a *PanicError, got %v", report.For("panics")[0].Err)
+		}
+		if report.For("panics")[0].Compatible {
+			t.Fatal("expected the panicking driver to be incompatible")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a driver panicking with nil not to hang the compatibility check")
+	}
+}
+
+func TestFilterForCompatiblePanic(t *testing.T) {
+	sink := &recordingSink{}
+	rg := NewRegistry(WithLogger(logr.New(sink)))
+	rg.Register("panics", "web", nil, nil, panicDriver{})
+	rg.Register("dell", "web", nil, nil, &driverOne{isCompatible: true})
+
+	compatible, report := rg.FilterForCompatibleReport(context.Background())
+	if diff := cmp.Diff(compatible.Names(), []string{"dell"}); diff != "" {
+		t.Fatal(diff)
+	}
+	var perr *PanicError
+	if !errors.As(report.For("panics")[0].Err, &perr) {
+		t.Fatalf("expected a *PanicError, got %v", report.For("panics")[0].Err)
+	}
+	if perr.Value != "third-party driver bug" {
+		t.Fatalf("unexpected panic value: %v", perr.Value)
+	}
+	if !strings.Contains(string(perr.Stack), "panicDriver") {
+		t.Fatalf("expected stack to include the panicking driver, got %s", perr.Stack)
+	}
+	if len(sink.errors) != 1 {
+		t.Fatalf("expected the panic to be logged once, got %v", sink.errors)
+	}
+}
@@ -0,0 +1,123 @@
+package registrar
+
+import (
+	"sort"
+	"strings"
+)
+
+// Filter returns the drivers for which keep returns true. Nil drivers are dropped. Order is preserved.
+func (d Drivers) Filter(keep func(*Driver) bool) Drivers {
+	var result Drivers
+	for _, reg := range d {
+		if reg != nil && keep(reg) {
+			result = append(result, reg)
+		}
+	}
+	return result
+}
+
+// Sort returns a copy of the drivers sorted by less. Drivers that compare equal keep their relative order.
+func (d Drivers) Sort(less func(a, b *Driver) bool) Drivers {
+	result := make(Drivers, len(d))
+	copy(result, d)
+	sort.SliceStable(result, func(i, j int) bool { return less(result[i], result[j]) })
+	return result
+}
+
+// Supports returns the drivers that support all of the features.
+func (d Drivers) Supports(features ...Feature) Drivers {
+	return d.Filter(func(reg *Driver) bool { return reg.Features.include(features...) })
+}
+
+// Using returns the drivers for a specific protocol type.
+func (d Drivers) Using(proto string) Drivers {
+	return d.Filter(func(reg *Driver) bool { return reg.Protocol == proto })
+}
+
+// For returns the drivers with a specific name.
+func (d Drivers) For(driver string) Drivers {
+	return d.Filter(func(reg *Driver) bool { return reg.Name == driver })
+}
+
+// Matching returns the drivers whose Labels match the selector.
+func (d Drivers) Matching(sel Selector) Drivers {
+	return d.Filter(func(reg *Driver) bool { return sel.Matches(reg.Labels) })
+}
+
+// Enabled returns the drivers that are not disabled.
+func (d Drivers) Enabled() Drivers {
+	return d.Filter(func(reg *Driver) bool { return !reg.Disabled })
+}
+
+// PreferProtocol returns the drivers with the preferred protocols moved to the start,
+// in the order the protocols are given. Protocols are matched case insensitively.
+func (d Drivers) PreferProtocol(protocols ...string) Drivers {
+	return d.prefer(deduplicate(protocols), func(reg *Driver) string { return reg.Protocol })
+}
+
+// PreferDriver returns the drivers with the preferred driver names moved to the start,
+// in the order the names are given. Names are matched case insensitively.
+func (d Drivers) PreferDriver(drivers ...string) Drivers {
+	return d.prefer(deduplicate(drivers), func(reg *Driver) string { return reg.Name })
+}
+
+// Names returns the name of every driver, in order, including repeated names. Nil drivers are skipped.
+func (d Drivers) Names() []string {
+	var names []string
+	for _, reg := range d {
+		if reg != nil {
+			names = append(names, reg.Name)
+		}
+	}
+	return names
+}
+
+// Protocols returns the distinct driver protocols, in order of first appearance.
+func (d Drivers) Protocols() []string {
+	return d.distinct(func(reg *Driver) string { return reg.Protocol })
+}
+
+// prefer does the actual work of moving drivers whose field matches one of
+// preferred to the start. Nil drivers are dropped.
+func (d Drivers) prefer(preferred []string, field func(*Driver) string) Drivers {
+	var final Drivers
+	var leftOver Drivers
+	tracking := make(map[int]Drivers)
+	for _, registry := range d {
+		if registry == nil {
+			continue
+		}
+		var movedToTracking bool
+		for index, pName := range preferred {
+			if strings.EqualFold(field(registry), pName) {
+				tracking[index] = append(tracking[index], registry)
+				movedToTracking = true
+			}
+		}
+		if !movedToTracking {
+			leftOver = append(leftOver, registry)
+		}
+	}
+	for x := 0; x < len(preferred); x++ {
+		final = append(final, tracking[x]...)
+	}
+	final = append(final, leftOver...)
+	return final
+}
+
+// distinct returns the distinct values of field, in order of first appearance.
+func (d Drivers) distinct(field func(*Driver) string) []string {
+	var result []string
+	seen := make(map[string]struct{})
+	for _, reg := range d {
+		if reg == nil {
+			continue
+		}
+		val := field(reg)
+		if _, ok := seen[val]; !ok {
+			result = append(result, val)
+			seen[val] = struct{}{}
+		}
+	}
+	return result
+}
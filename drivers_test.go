@@ -0,0 +1,73 @@
+package registrar
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func testDrivers() Drivers {
+	return Drivers{
+		{Name: "dell", Protocol: "web", Features: Features{FeaturePowerSet}},
+		{Name: "ipmitool", Protocol: "ipmi", Features: Features{FeaturePowerSet, FeatureUserCreate}},
+		nil,
+		{Name: "smc", Protocol: "redfish", Features: Features{FeatureUserCreate}},
+		{Name: "smc", Protocol: "web", Features: Features{FeaturePowerSet}},
+	}
+}
+
+func TestDriversChaining(t *testing.T) {
+	testCases := map[string]struct {
+		got       Drivers
+		want      []string
+		wantProto []string
+	}{
+		"supports then using":  {got: testDrivers().Supports(FeaturePowerSet).Using("web"), want: []string{"dell", "smc"}, wantProto: []string{"web"}},
+		"for then prefer":      {got: testDrivers().For("smc").PreferProtocol("web"), want: []string{"smc", "smc"}, wantProto: []string{"web", "redfish"}},
+		"prefer driver":        {got: testDrivers().PreferDriver("smc", "ipmitool"), want: []string{"smc", "smc", "ipmitool", "dell"}, wantProto: []string{"redfish", "web", "ipmi"}},
+		"prefer missing first": {got: testDrivers().PreferProtocol("bogus", "ipmi"), want: []string{"ipmitool", "dell", "smc", "smc"}, wantProto: []string{"ipmi", "web", "redfish"}},
+		"filter":               {got: testDrivers().Filter(func(d *Driver) bool { return len(d.Features) == 2 }), want: []string{"ipmitool"}, wantProto: []string{"ipmi"}},
+		"sort": {
+			got:       testDrivers().Sort(func(a, b *Driver) bool { return a != nil && b != nil && a.Protocol < b.Protocol }).Using("web"),
+			want:      []string{"dell", "smc"},
+			wantProto: []string{"web"},
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.got.Names(), tc.want); diff != "" {
+				t.Fatal(diff)
+			}
+			if diff := cmp.Diff(tc.got.Protocols(), tc.wantProto); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestDriversSort(t *testing.T) {
+	drivers := testDrivers().Filter(func(*Driver) bool { return true })
+	sorted := drivers.Sort(func(a, b *Driver) bool { return a.Name > b.Name })
+	want := Drivers{drivers[2], drivers[3], drivers[1], drivers[0]}
+	if diff := cmp.Diff(sorted, want); diff != "" {
+		t.Fatal(diff)
+	}
+	if drivers[0].Name != "dell" {
+		t.Fatal("expected Sort to leave the receiver unchanged")
+	}
+}
+
+func TestRegistryWrappers(t *testing.T) {
+	drivers := testDrivers().Filter(func(*Driver) bool { return true })
+	rg := NewRegistry(WithDrivers(drivers))
+	if diff := cmp.Diff(rg.Supports(FeatureUserCreate), drivers.Supports(FeatureUserCreate)); diff != "" {
+		t.Fatal(diff)
+	}
+	if diff := cmp.Diff(rg.PreferProtocol("redfish"), drivers.PreferProtocol("redfish")); diff != "" {
+		t.Fatal(diff)
+	}
+	if diff := cmp.Diff(rg.PreferDriver("SMC"), drivers.PreferDriver("smc")); diff != "" {
+		t.Fatal(diff)
+	}
+}
@@ -0,0 +1,184 @@
+package registrar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNoDrivers is returned by the executors when there are no drivers to try.
+var ErrNoDrivers = errors.New("no drivers to execute")
+
+// ExecOption for setting optional executor values.
+type ExecOption func(*execConfig)
+
+// execConfig holds the settings used by the executors.
+type execConfig struct {
+	// recorder, when set, records the outcome of every driver call under target.
+	recorder *OutcomeRecorder
+	target   string
+	// breakers, when set, skips drivers whose circuit is open and records the outcome of every call.
+	breakers *Breakers
+	// parallelism bounds the number of drivers the concurrent executors call at once.
+	parallelism int
+	// retry holds the retry policies of every driver.
+	retry retryPolicies
+}
+
+// WithOutcomeRecorder records the outcome and latency of every driver call under target.
+func WithOutcomeRecorder(recorder *OutcomeRecorder, target string) ExecOption {
+	return func(args *execConfig) {
+		args.recorder = recorder
+		args.target = target
+	}
+}
+
+// WithBreakers skips drivers whose circuit is open, recording ErrCircuitOpen for them,
+// and records the outcome of every driver call in breakers.
+func WithBreakers(breakers *Breakers) ExecOption {
+	return func(args *execConfig) { args.breakers = breakers }
+}
+
+// WithParallelism sets the maximum number of drivers the concurrent executors call at the same time.
+// A value less than 1 means no limit.
+func WithParallelism(n int) ExecOption {
+	return func(args *execConfig) { args.parallelism = n }
+}
+
+// newExecConfig returns the executor settings with opts applied.
+func newExecConfig(opts ...ExecOption) *execConfig {
+	cfg := &execConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// call runs fn for a single driver, retrying according to the driver's retry policy,
+// and returns the number of attempts made. The outcome of every attempt is recorded.
+// Drivers whose circuit is open are not called.
+func (c *execConfig) call(ctx context.Context, reg *Driver, fn func(context.Context, *Driver) error) (int, error) {
+	policy := c.retry.forDriver(reg)
+	var lastErr error
+	for attempts := 1; ; attempts++ {
+		err := c.attempt(ctx, reg, fn)
+		if errors.Is(err, ErrCircuitOpen) {
+			if lastErr != nil {
+				err = fmt.Errorf("%w: %w", err, lastErr)
+			}
+			return attempts - 1, err
+		}
+		lastErr = err
+		if err == nil || attempts >= policy.MaxAttempts || !policy.retryable(err) {
+			return attempts, err
+		}
+		if serr := sleep(ctx, policy.Backoff(attempts)); serr != nil {
+			return attempts, err
+		}
+	}
+}
+
+// attempt runs fn for a single driver once and records its outcome. Calls that fail because
+// ctx was canceled, for example the losers of Race, are not recorded as the driver is not at fault.
+func (c *execConfig) attempt(ctx context.Context, reg *Driver, fn func(context.Context, *Driver) error) error {
+	if c.breakers != nil && !c.breakers.Allow(reg) {
+		return ErrCircuitOpen
+	}
+	start := time.Now()
+	err := fn(ctx, reg)
+	if cerr := ctx.Err(); cerr != nil && errors.Is(err, cerr) {
+		if c.breakers != nil {
+			c.breakers.release(reg)
+		}
+		return err
+	}
+	if c.recorder != nil {
+		c.recorder.Record(c.target, reg, err, time.Since(start))
+	}
+	if c.breakers != nil {
+		c.breakers.Record(reg, err)
+	}
+	return err
+}
+
+// DriverError holds the error a single driver returned during an execution.
+type DriverError struct {
+	Name     string
+	Protocol string
+	// Attempts is the number of times the driver was called, including retries.
+	Attempts int
+	Err      error
+}
+
+// Error implements the error interface.
+func (e *DriverError) Error() string {
+	return fmt.Sprintf("driver %q (protocol %q): %v", e.Name, e.Protocol, e.Err)
+}
+
+// Unwrap returns the underlying driver error.
+func (e *DriverError) Unwrap() error {
+	return e.Err
+}
+
+// DriverErrors is a collection of per driver errors, in the order the drivers were tried.
+type DriverErrors []*DriverError
+
+// Error implements the error interface.
+func (e DriverErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d driver(s) failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the individual driver errors.
+func (e DriverErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// For returns the errors of every driver with the given name.
+func (e DriverErrors) For(name string) DriverErrors {
+	var result DriverErrors
+	for _, err := range e {
+		if err.Name == name {
+			result = append(result, err)
+		}
+	}
+	return result
+}
+
+// FirstSuccess calls fn for each driver, in order, until one returns a nil error.
+// The driver that succeeded is returned. When no driver succeeds the returned error
+// is a DriverErrors holding the error of every driver that was tried. Nil drivers are skipped.
+// If ctx is canceled before a driver is tried, the context error is recorded for that
+// driver and no further drivers are tried.
+func FirstSuccess(ctx context.Context, drivers Drivers, fn func(context.Context, *Driver) error, opts ...ExecOption) (*Driver, error) {
+	cfg := newExecConfig(opts...)
+	var errs DriverErrors
+	for _, elem := range drivers {
+		if elem == nil {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, &DriverError{Name: elem.Name, Protocol: elem.Protocol, Err: err})
+			break
+		}
+		if attempts, err := cfg.call(ctx, elem, fn); err != nil {
+			errs = append(errs, &DriverError{Name: elem.Name, Protocol: elem.Protocol, Attempts: attempts, Err: err})
+			continue
+		}
+		return elem, nil
+	}
+	if len(errs) == 0 {
+		return nil, ErrNoDrivers
+	}
+
+	return nil, errs
+}
@@ -0,0 +1,91 @@
+package registrar
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var errDriverFailed = errors.New("driver failed")
+
+func TestFirstSuccess(t *testing.T) {
+	dell := &Driver{Name: "dell", Protocol: "web"}
+	ipmi := &Driver{Name: "ipmitool", Protocol: "ipmi"}
+	smc := &Driver{Name: "smc", Protocol: "web"}
+	testCases := map[string]struct {
+		drivers   Drivers
+		failing   map[string]bool
+		want      *Driver
+		wantTried []string
+		wantErrs  []string
+	}{
+		"first driver succeeds":  {drivers: Drivers{dell, ipmi, smc}, want: dell, wantTried: []string{"dell"}},
+		"second driver succeeds": {drivers: Drivers{dell, ipmi, smc}, failing: map[string]bool{"dell": true}, want: ipmi, wantTried: []string{"dell", "ipmitool"}},
+		"nil drivers skipped":    {drivers: Drivers{nil, smc}, want: smc, wantTried: []string{"smc"}},
+		"all drivers fail": {
+			drivers:   Drivers{dell, ipmi},
+			failing:   map[string]bool{"dell": true, "ipmitool": true},
+			wantTried: []string{"dell", "ipmitool"},
+			wantErrs:  []string{"dell", "ipmitool"},
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			var tried []string
+			got, err := FirstSuccess(context.Background(), tc.drivers, func(_ context.Context, d *Driver) error {
+				tried = append(tried, d.Name)
+				if tc.failing[d.Name] {
+					return errDriverFailed
+				}
+				return nil
+			})
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Fatal(diff)
+			}
+			if diff := cmp.Diff(tried, tc.wantTried); diff != "" {
+				t.Fatal(diff)
+			}
+			var gotErrs []string
+			var derrs DriverErrors
+			if errors.As(err, &derrs) {
+				for _, e := range derrs {
+					gotErrs = append(gotErrs, e.Name)
+				}
+			}
+			if diff := cmp.Diff(gotErrs, tc.wantErrs); diff != "" {
+				t.Fatal(diff)
+			}
+			if tc.want == nil && !errors.Is(err, errDriverFailed) {
+				t.Fatalf("expected error to wrap %v, got %v", errDriverFailed, err)
+			}
+		})
+	}
+}
+
+func TestFirstSuccessNoDrivers(t *testing.T) {
+	_, err := FirstSuccess(context.Background(), nil, func(context.Context, *Driver) error { return nil })
+	if !errors.Is(err, ErrNoDrivers) {
+		t.Fatalf("expected %v, got %v", ErrNoDrivers, err)
+	}
+}
+
+func TestFirstSuccessCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	drivers := Drivers{{Name: "dell", Protocol: "web"}, {Name: "smc", Protocol: "web"}}
+	var called bool
+	_, err := FirstSuccess(ctx, drivers, func(context.Context, *Driver) error {
+		called = true
+		return nil
+	})
+	if called {
+		t.Fatal("expected no driver to be called")
+	}
+	var derrs DriverErrors
+	if !errors.As(err, &derrs) || len(derrs) != 1 || !errors.Is(derrs[0], context.Canceled) {
+		t.Fatalf("expected a single context.Canceled driver error, got %v", err)
+	}
+}
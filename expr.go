@@ -0,0 +1,233 @@
+package registrar
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FeatureExpr is a boolean expression over features, see ParseFeatureExpr.
+type FeatureExpr interface {
+	// Eval reports whether the features satisfy the expression.
+	Eval(Features) bool
+	// String returns the expression in feature expression syntax.
+	String() string
+}
+
+// FeatureExprError is returned by ParseFeatureExpr for an expression that can not be parsed.
+type FeatureExprError struct {
+	Expr string
+	// Pos is the byte offset in Expr where the problem was found.
+	Pos int
+	Msg string
+}
+
+// Error implements the error interface.
+func (e *FeatureExprError) Error() string {
+	return fmt.Sprintf("invalid feature expression %q at position %d: %s", e.Expr, e.Pos, e.Msg)
+}
+
+// featureRef is satisfied when the feature is present.
+type featureRef Feature
+
+// notExpr negates an expression.
+type notExpr struct{ x FeatureExpr }
+
+// andExpr is satisfied when both sides are.
+type andExpr struct{ x, y FeatureExpr }
+
+// orExpr is satisfied when either side is.
+type orExpr struct{ x, y FeatureExpr }
+
+func (f featureRef) Eval(features Features) bool { return features.include(Feature(f)) }
+func (f featureRef) String() string              { return string(f) }
+func (n notExpr) Eval(features Features) bool    { return !n.x.Eval(features) }
+func (n notExpr) String() string                 { return "!" + n.x.String() }
+func (a andExpr) Eval(features Features) bool    { return a.x.Eval(features) && a.y.Eval(features) }
+func (a andExpr) String() string                 { return "(" + a.x.String() + " && " + a.y.String() + ")" }
+func (o orExpr) Eval(features Features) bool     { return o.x.Eval(features) || o.y.Eval(features) }
+func (o orExpr) String() string                  { return "(" + o.x.String() + " || " + o.y.String() + ")" }
+
+// includeAny reports whether at least one of features is included.
+func (f Features) includeAny(features ...Feature) bool {
+	for _, feature := range features {
+		if f.include(feature) {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsAny returns the drivers that support at least one of the features.
+func (d Drivers) SupportsAny(features ...Feature) Drivers {
+	return d.Filter(func(reg *Driver) bool { return reg.Features.includeAny(features...) })
+}
+
+// SupportsNone returns the drivers that support none of the features.
+func (d Drivers) SupportsNone(features ...Feature) Drivers {
+	return d.Filter(func(reg *Driver) bool { return !reg.Features.includeAny(features...) })
+}
+
+// Satisfying returns the drivers whose features satisfy the expression.
+func (d Drivers) Satisfying(expr FeatureExpr) Drivers {
+	return d.Filter(func(reg *Driver) bool { return expr.Eval(reg.Features) })
+}
+
+// SupportsAny does the actual work of filtering for drivers with at least one of the features.
+func (r *Registry) SupportsAny(features ...Feature) Drivers {
+	drivers := r.active()
+	supportedRegistries := drivers.SupportsAny(features...)
+	r.logFiltered("SupportsAny", features, drivers, supportedRegistries)
+	return supportedRegistries
+}
+
+// SupportsNone does the actual work of filtering for drivers with none of the features.
+func (r *Registry) SupportsNone(features ...Feature) Drivers {
+	drivers := r.active()
+	supportedRegistries := drivers.SupportsNone(features...)
+	r.logFiltered("SupportsNone", features, drivers, supportedRegistries)
+	return supportedRegistries
+}
+
+// SupportsExpr returns the drivers whose features satisfy the expression, see ParseFeatureExpr for the syntax.
+func (r *Registry) SupportsExpr(expr string) (Drivers, error) {
+	parsed, err := ParseFeatureExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	return r.Satisfying(parsed), nil
+}
+
+// Satisfying returns the drivers whose features satisfy an already parsed expression.
+func (r *Registry) Satisfying(expr FeatureExpr) Drivers {
+	drivers := r.active()
+	supportedRegistries := drivers.Satisfying(expr)
+	r.logFiltered("Satisfying", expr.String(), drivers, supportedRegistries)
+	return supportedRegistries
+}
+
+// ParseFeatureExpr parses a boolean feature expression.
+// Feature names are combined with && (and), || (or), ! (not) and parentheses.
+// ! binds tightest, then &&, then ||. For example:
+//
+//	powerset && (bootdevice || firmware.update) && !legacy
+func ParseFeatureExpr(expr string) (FeatureExpr, error) {
+	p := &exprParser{input: expr}
+	x, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		if p.input[p.pos] == ')' {
+			return nil, p.errorf("unexpected ')' without a matching '('")
+		}
+		return nil, p.errorf("expected && or || but found %q", p.input[p.pos])
+	}
+	return x, nil
+}
+
+// exprParser holds the state of parsing a feature expression.
+type exprParser struct {
+	input string
+	pos   int
+}
+
+// or parses expressions joined by ||.
+func (p *exprParser) or() (FeatureExpr, error) {
+	x, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume("||") {
+		y, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		x = orExpr{x: x, y: y}
+	}
+	return x, nil
+}
+
+// and parses expressions joined by &&.
+func (p *exprParser) and() (FeatureExpr, error) {
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume("&&") {
+		y, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		x = andExpr{x: x, y: y}
+	}
+	return x, nil
+}
+
+// unary parses a negated or primary expression.
+func (p *exprParser) unary() (FeatureExpr, error) {
+	if p.consume("!") {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{x: x}, nil
+	}
+	return p.primary()
+}
+
+// primary parses a parenthesized expression or a feature name.
+func (p *exprParser) primary() (FeatureExpr, error) {
+	if p.consume("(") {
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			if p.pos == len(p.input) {
+				return nil, p.errorf("expected ')' but reached the end")
+			}
+			return nil, p.errorf("expected ')' but found %q", p.input[p.pos])
+		}
+		return x, nil
+	}
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.input) && isFeatureChar(p.input[p.pos]) {
+		p.pos++
+	}
+	if start == p.pos {
+		if p.pos == len(p.input) {
+			return nil, p.errorf("expected a feature but reached the end")
+		}
+		return nil, p.errorf("expected a feature but found %q", p.input[p.pos])
+	}
+	return featureRef(p.input[start:p.pos]), nil
+}
+
+// consume skips spaces and advances past s if the input continues with it.
+func (p *exprParser) consume(s string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.input[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+// skipSpace advances past any white space.
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.input) && strings.IndexByte(" \t\n\r", p.input[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// errorf returns a *FeatureExprError at the current position.
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+	return &FeatureExprError{Expr: p.input, Pos: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// isFeatureChar reports whether c may be used in a feature name.
+func isFeatureChar(c byte) bool {
+	return isSelectorChar(c) || c == ':'
+}
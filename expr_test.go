@@ -0,0 +1,118 @@
+package registrar
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	featureBootDevice     Feature = "bootdevice"
+	featureFirmwareUpdate Feature = "firmware.update"
+	featureLegacy         Feature = "legacy"
+)
+
+func TestFeatureExprEval(t *testing.T) {
+	testCases := map[string]struct {
+		expr     string
+		features Features
+		want     bool
+		wantStr  string
+	}{
+		"single present":   {expr: "powerset", features: Features{FeaturePowerSet}, want: true, wantStr: "powerset"},
+		"single missing":   {expr: "powerset", features: Features{featureLegacy}, want: false, wantStr: "powerset"},
+		"or":               {expr: "powerset || bootdevice", features: Features{featureBootDevice}, want: true, wantStr: "(powerset || bootdevice)"},
+		"not":              {expr: "!legacy", features: Features{featureLegacy}, want: false, wantStr: "!legacy"},
+		"double not":       {expr: "!!legacy", features: Features{featureLegacy}, want: true, wantStr: "!!legacy"},
+		"precedence":       {expr: "powerset || bootdevice && legacy", features: Features{FeaturePowerSet}, want: true, wantStr: "(powerset || (bootdevice && legacy))"},
+		"nested match":     {expr: "powerset && (bootdevice || firmware.update) && !legacy", features: Features{FeaturePowerSet, featureFirmwareUpdate}, want: true, wantStr: "((powerset && (bootdevice || firmware.update)) && !legacy)"},
+		"nested excluded":  {expr: "powerset && (bootdevice || firmware.update) && !legacy", features: Features{FeaturePowerSet, featureBootDevice, featureLegacy}, want: false},
+		"nested no subset": {expr: "powerset&&(bootdevice||firmware.update)", features: Features{FeaturePowerSet}, want: false},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			expr, err := ParseFeatureExpr(tc.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := expr.Eval(tc.features); got != tc.want {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+			if tc.wantStr != "" {
+				if diff := cmp.Diff(expr.String(), tc.wantStr); diff != "" {
+					t.Fatal(diff)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFeatureExprErrors(t *testing.T) {
+	testCases := map[string]struct {
+		expr    string
+		wantPos int
+	}{
+		"empty":            {expr: "", wantPos: 0},
+		"dangling and":     {expr: "powerset &&", wantPos: 11},
+		"single pipe":      {expr: "powerset | bootdevice", wantPos: 9},
+		"unclosed paren":   {expr: "(powerset || bootdevice", wantPos: 23},
+		"unopened paren":   {expr: "powerset)", wantPos: 8},
+		"missing operator": {expr: "powerset bootdevice", wantPos: 9},
+		"bad character":    {expr: "powerset && $x", wantPos: 12},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseFeatureExpr(tc.expr)
+			var exprErr *FeatureExprError
+			if !errors.As(err, &exprErr) {
+				t.Fatalf("expected a *FeatureExprError, got %v", err)
+			}
+			if exprErr.Pos != tc.wantPos {
+				t.Fatalf("expected error at position %d, got %d: %v", tc.wantPos, exprErr.Pos, err)
+			}
+		})
+	}
+}
+
+func TestSupportsMatchingModes(t *testing.T) {
+	rg := NewRegistry()
+	rg.Register("redfish", "redfish", Features{FeaturePowerSet, featureBootDevice}, nil, nil)
+	rg.Register("ipmitool", "ipmi", Features{FeaturePowerSet, featureLegacy}, nil, nil)
+	rg.Register("web", "web", Features{featureFirmwareUpdate}, nil, nil)
+	rg.Register("bare", "web", nil, nil, nil)
+
+	if diff := cmp.Diff(rg.SupportsAny(featureBootDevice, featureFirmwareUpdate).Names(), []string{"redfish", "web"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if diff := cmp.Diff(rg.SupportsNone(featureLegacy, featureFirmwareUpdate).Names(), []string{"redfish", "bare"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if got := rg.SupportsAny(); len(got) != 0 {
+		t.Fatalf("expected no drivers to support any of no features, got %v", got.Names())
+	}
+	got, err := rg.SupportsExpr("(powerset || firmware.update) && !legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(got.Names(), []string{"redfish", "web"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if _, err := rg.SupportsExpr("powerset &&"); err == nil {
+		t.Fatal("expected an error for an invalid expression")
+	}
+
+	expr, err := ParseFeatureExpr("!legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := rg.Query().SupportsAny(FeaturePowerSet).Satisfying(expr)
+	if diff := cmp.Diff(query.Drivers().Names(), []string{"redfish"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if diff := cmp.Diff(rg.Query().SupportsNone(FeaturePowerSet).Drivers().Names(), []string{"web", "bare"}); diff != "" {
+		t.Fatal(diff)
+	}
+}
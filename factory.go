@@ -0,0 +1,93 @@
+package registrar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNoFactory is returned by Instantiate for a driver that has neither a DriverInterface nor a Factory.
+var ErrNoFactory = errors.New("driver has no driver interface and no factory")
+
+// Config holds the settings a Factory builds a driver from, for example the host and credentials of a BMC.
+type Config map[string]interface{}
+
+// Factory builds a driver implementation. The config must not be modified.
+type Factory func(ctx context.Context, config Config) (interface{}, error)
+
+// RegisterFactory will add a driver to a Driver registry whose implementation is only built,
+// by factory, when Instantiate is called. This allows drivers to be filtered before paying for
+// their construction, for example of HTTP clients or session state.
+func (r *Registry) RegisterFactory(name, protocol string, features Features, metadata interface{}, factory Factory, opts ...DriverOption) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := newDriver(name, protocol, features, metadata, nil, opts...)
+	d.Factory = factory
+	r.Drivers = append(r.Drivers, d)
+	r.emit(EventRegistered, d)
+	r.Logger.V(1).Info("driver registered", "name", name, "protocol", protocol, "features", features, "factory", true)
+}
+
+// Instantiate concurrently builds, with the registry Config, the drivers that have a Factory
+// but no DriverInterface and returns, in order, the drivers that are ready for use.
+// Built drivers are returned as copies with DriverInterface set; the registered drivers are not
+// modified, so every call builds new implementations. Drivers that already have a DriverInterface
+// are returned as is. When a driver fails to build the returned error is a DriverErrors holding
+// the error of every driver that failed.
+func (r *Registry) Instantiate(ctx context.Context, drivers Drivers) (Drivers, error) {
+	var wg sync.WaitGroup
+	built := make(Drivers, len(drivers))
+	errs := make([]error, len(drivers))
+	for idx, elem := range drivers {
+		if elem == nil || elem.DriverInterface != nil {
+			built[idx] = elem
+			continue
+		}
+		if elem.Factory == nil {
+			errs[idx] = ErrNoFactory
+			continue
+		}
+		wg.Add(1)
+		go func(reg *Driver, num int) {
+			defer wg.Done()
+			start := time.Now()
+			impl, err := reg.Factory(ctx, r.Config)
+			if err == nil && isNil(impl) {
+				err = fmt.Errorf("factory returned no implementation: %w", ErrNilDriverInterface)
+			}
+			r.logLifecycle("instantiate", reg, time.Since(start), err)
+			if err != nil {
+				errs[num] = err
+				return
+			}
+			inst := *reg
+			inst.DriverInterface = impl
+			built[num] = &inst
+		}(elem, idx)
+	}
+	wg.Wait()
+
+	var ready Drivers
+	var failed DriverErrors
+	for idx, elem := range drivers {
+		if elem == nil {
+			continue
+		}
+		if errs[idx] != nil {
+			attempts := 1
+			if errors.Is(errs[idx], ErrNoFactory) {
+				attempts = 0
+			}
+			failed = append(failed, &DriverError{Name: elem.Name, Protocol: elem.Protocol, Attempts: attempts, Err: errs[idx]})
+			continue
+		}
+		ready = append(ready, built[idx])
+	}
+	if len(failed) > 0 {
+		return ready, failed
+	}
+
+	return ready, nil
+}
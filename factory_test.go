@@ -0,0 +1,68 @@
+package registrar
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInstantiate(t *testing.T) {
+	var built int32
+	factory := func(_ context.Context, config Config) (interface{}, error) {
+		atomic.AddInt32(&built, 1)
+		return &driverOne{name: config["host"].(string)}, nil
+	}
+	rg := NewRegistry(WithConfig(Config{"host": "10.0.0.1"}))
+	rg.RegisterFactory("lazy", "redfish", Features{FeaturePowerSet}, nil, factory)
+	rg.RegisterFactory("filtered", "ipmi", Features{FeaturePowerSet}, nil, factory)
+	rg.RegisterFactory("broken", "redfish", nil, nil, func(context.Context, Config) (interface{}, error) { return nil, errDriverFailed })
+	rg.RegisterFactory("empty", "redfish", nil, nil, func(context.Context, Config) (interface{}, error) { return nil, nil })
+	rg.Register("eager", "redfish", nil, nil, &driverOne{name: "eager"})
+	rg.Drivers = append(rg.Drivers, &Driver{Name: "nothing", Protocol: "redfish"})
+
+	ready, err := rg.Instantiate(context.Background(), rg.Using("redfish"))
+	if diff := cmp.Diff(ready.Names(), []string{"lazy", "eager"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if n := atomic.LoadInt32(&built); n != 1 {
+		t.Fatalf("expected only the driver that survived filtering to be built, got %d builds", n)
+	}
+	if got := ready[0].DriverInterface.(*driverOne).name; got != "10.0.0.1" {
+		t.Fatalf("expected the registry config to be passed to the factory, got %q", got)
+	}
+	if rg.Drivers[0].DriverInterface != nil {
+		t.Fatal("expected the registered driver to be left untouched")
+	}
+
+	var derrs DriverErrors
+	if !errors.As(err, &derrs) {
+		t.Fatalf("expected DriverErrors, got %v", err)
+	}
+	tests := map[string]error{
+		"broken":  errDriverFailed,
+		"empty":   ErrNilDriverInterface,
+		"nothing": ErrNoFactory,
+	}
+	for name, want := range tests {
+		got := derrs.For(name)
+		if len(got) != 1 || !errors.Is(got[0], want) {
+			t.Errorf("%v: expected %v, got %v", name, want, got)
+		}
+	}
+}
+
+func TestQueryInstantiate(t *testing.T) {
+	rg := NewRegistry(WithConfig(Config{"host": "10.0.0.2"}))
+	rg.RegisterFactory("lazy", "redfish", nil, nil, func(_ context.Context, config Config) (interface{}, error) {
+		return &driverOne{name: config["host"].(string)}, nil
+	})
+	rg.RegisterFactory("broken", "redfish", nil, nil, func(context.Context, Config) (interface{}, error) { return nil, errDriverFailed })
+
+	got := Implementations[*driverOne](rg.Query().Using("redfish").Instantiate(context.Background()).Drivers())
+	if len(got) != 1 || got[0].name != "10.0.0.2" {
+		t.Fatalf("expected the lazy driver built with the registry config, got %v", got)
+	}
+}
@@ -0,0 +1,169 @@
+package registrar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrNotRun is recorded for a driver that was not called because the execution finished or was canceled first.
+	ErrNotRun = errors.New("driver was not run")
+	// ErrNoQuorum is returned by Quorum when not enough drivers agreed on a result.
+	ErrNoQuorum = errors.New("quorum not reached")
+)
+
+// Result holds the outcome of running an operation against a single driver.
+type Result[T any] struct {
+	Driver *Driver
+	Value  T
+	Err    error
+	// Attempts is the number of times the driver was called, including retries.
+	Attempts int
+	Duration time.Duration
+}
+
+// All calls fn for every driver concurrently and returns every result, in driver order.
+// Nil drivers are skipped. See WithParallelism to bound the number of concurrent calls.
+func All[T any](ctx context.Context, drivers Drivers, fn func(context.Context, *Driver) (T, error), opts ...ExecOption) []Result[T] {
+	return fanOut(ctx, drivers, fn, newExecConfig(opts...), func(Result[T]) bool { return false })
+}
+
+// Race calls fn for every driver concurrently and returns the result of the first driver
+// to succeed. The context passed to the other calls is canceled once a driver succeeds,
+// and drivers not yet started are not called. Race waits for every started call to return.
+// All results are returned, in driver order. When no driver succeeds the error is a DriverErrors,
+// or ErrNoDrivers when there are no drivers.
+func Race[T any](ctx context.Context, drivers Drivers, fn func(context.Context, *Driver) (T, error), opts ...ExecOption) (Result[T], []Result[T], error) {
+	var winner *Result[T]
+	results := fanOut(ctx, drivers, fn, newExecConfig(opts...), func(res Result[T]) bool {
+		if res.Err == nil && winner == nil {
+			winner = &res
+		}
+		return winner != nil
+	})
+	if winner == nil {
+		if errs := resultErrors(results); len(errs) > 0 {
+			return Result[T]{}, results, errs
+		}
+		return Result[T]{}, results, ErrNoDrivers
+	}
+
+	return *winner, results, nil
+}
+
+// Quorum calls fn for every driver concurrently until quorum successful results are equal,
+// as determined by equal. The agreed value is returned and the context passed to the
+// remaining calls is canceled. Quorum waits for every started call to return.
+// All results are returned, in driver order. When no quorum is reached the error wraps ErrNoQuorum.
+func Quorum[T any](ctx context.Context, drivers Drivers, quorum int, fn func(context.Context, *Driver) (T, error), equal func(a, b T) bool, opts ...ExecOption) (T, []Result[T], error) {
+	var groups [][]T
+	var agreed *T
+	results := fanOut(ctx, drivers, fn, newExecConfig(opts...), func(res Result[T]) bool {
+		if res.Err != nil || agreed != nil {
+			return agreed != nil
+		}
+		for i, group := range groups {
+			if equal(group[0], res.Value) {
+				groups[i] = append(group, res.Value)
+				if len(groups[i]) >= quorum {
+					agreed = &groups[i][0]
+				}
+				return agreed != nil
+			}
+		}
+		groups = append(groups, []T{res.Value})
+		if quorum <= 1 {
+			agreed = &groups[len(groups)-1][0]
+		}
+		return agreed != nil
+	})
+	if agreed == nil {
+		var zero T
+		err := fmt.Errorf("%w: needed %d agreeing results", ErrNoQuorum, quorum)
+		if errs := resultErrors(results); len(errs) > 0 {
+			err = fmt.Errorf("%w: %w", err, errs)
+		}
+		return zero, results, err
+	}
+
+	return *agreed, results, nil
+}
+
+// fanOut calls fn for every non nil driver concurrently. Drivers are started in order, by a single
+// dispatcher, as slots allowed by WithParallelism free up. Each result is passed to stop, one at a time;
+// once it returns true the context of outstanding calls is canceled, before any further driver could
+// be started, and drivers not yet started record ErrNotRun. The results are in driver order.
+func fanOut[T any](ctx context.Context, drivers Drivers, fn func(context.Context, *Driver) (T, error), cfg *execConfig, stop func(Result[T]) bool) []Result[T] {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var todo Drivers
+	for _, elem := range drivers {
+		if elem != nil {
+			todo = append(todo, elem)
+		}
+	}
+	limit := cfg.parallelism
+	if limit < 1 || limit > len(todo) {
+		limit = len(todo)
+	}
+	type indexed struct {
+		num int
+		res Result[T]
+	}
+	// done is buffered so calls never wait on the dispatcher to deliver their result.
+	done := make(chan indexed, len(todo))
+	results := make([]Result[T], len(todo))
+	var next, running int
+	dispatch := func() {
+		for running < limit && next < len(todo) {
+			num := next
+			next++
+			if err := ctx.Err(); err != nil {
+				results[num] = Result[T]{Driver: todo[num], Err: fmt.Errorf("%w: %w", ErrNotRun, err)}
+				continue
+			}
+			running++
+			go func(reg *Driver) {
+				done <- indexed{num: num, res: callValue(ctx, cfg, reg, fn)}
+			}(todo[num])
+		}
+	}
+
+	dispatch()
+	for running > 0 {
+		elem := <-done
+		running--
+		results[elem.num] = elem.res
+		if stop(elem.res) {
+			cancel()
+		}
+		dispatch()
+	}
+	return results
+}
+
+// callValue calls fn for a single driver, through cfg, and returns its result.
+func callValue[T any](ctx context.Context, cfg *execConfig, reg *Driver, fn func(context.Context, *Driver) (T, error)) Result[T] {
+	var value T
+	start := time.Now()
+	attempts, err := cfg.call(ctx, reg, func(ctx context.Context, d *Driver) error {
+		var err error
+		value, err = fn(ctx, d)
+		return err
+	})
+	return Result[T]{Driver: reg, Value: value, Err: err, Attempts: attempts, Duration: time.Since(start)}
+}
+
+// resultErrors returns the errors of the failed results, in result order.
+func resultErrors[T any](results []Result[T]) DriverErrors {
+	var errs DriverErrors
+	for _, res := range results {
+		if res.Err != nil {
+			errs = append(errs, &DriverError{Name: res.Driver.Name, Protocol: res.Driver.Protocol, Attempts: res.Attempts, Err: res.Err})
+		}
+	}
+	return errs
+}
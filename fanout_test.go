@@ -0,0 +1,175 @@
+package registrar
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fanOutDrivers returns drivers named after how they behave in the fan-out tests.
+func fanOutDrivers() Drivers {
+	return Drivers{
+		{Name: "slow", Protocol: "web"},
+		{Name: "fails", Protocol: "ipmi"},
+		nil,
+		{Name: "fast", Protocol: "redfish"},
+	}
+}
+
+// fanOutFn returns the power state a driver reports. slow waits for cancellation or a second.
+func fanOutFn(ctx context.Context, d *Driver) (string, error) {
+	switch d.Name {
+	case "slow":
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(time.Second):
+			return "on", nil
+		}
+	case "fails":
+		return "", errDriverFailed
+	default:
+		return "on", nil
+	}
+}
+
+func TestAllResults(t *testing.T) {
+	results := All(context.Background(), fanOutDrivers(), func(_ context.Context, d *Driver) (int, error) {
+		if d.Name == "fails" {
+			return 0, errDriverFailed
+		}
+		return len(d.Name), nil
+	})
+	var got []interface{}
+	for _, res := range results {
+		got = append(got, res.Driver.Name, res.Value, res.Err != nil)
+	}
+	want := []interface{}{"slow", 4, false, "fails", 0, true, "fast", 4, false}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestAllParallelism(t *testing.T) {
+	var inFlight, maxSeen int32
+	drivers := make(Drivers, 10)
+	for i := range drivers {
+		drivers[i] = &Driver{Name: "driver", Protocol: "tcp"}
+	}
+	results := All(context.Background(), drivers, func(context.Context, *Driver) (bool, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			seen := atomic.LoadInt32(&maxSeen)
+			if n <= seen || atomic.CompareAndSwapInt32(&maxSeen, seen, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return true, nil
+	}, WithParallelism(2))
+	if len(results) != 10 {
+		t.Fatalf("expected 10 results, got %d", len(results))
+	}
+	if maxSeen > 2 {
+		t.Fatalf("expected at most 2 concurrent calls, got %d", maxSeen)
+	}
+}
+
+func TestRace(t *testing.T) {
+	start := time.Now()
+	winner, results, err := Race(context.Background(), fanOutDrivers(), fanOutFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected the slow driver to be canceled, took %v", elapsed)
+	}
+	if winner.Driver.Name != "fast" || winner.Value != "on" {
+		t.Fatalf("expected fast to win, got %+v", winner)
+	}
+	if !errors.Is(results[0].Err, context.Canceled) {
+		t.Fatalf("expected the losing driver to be canceled, got %v", results[0].Err)
+	}
+
+	_, _, err = Race(context.Background(), Drivers{{Name: "fails", Protocol: "ipmi"}}, fanOutFn)
+	var derrs DriverErrors
+	if !errors.As(err, &derrs) || len(derrs) != 1 {
+		t.Fatalf("expected a single driver error, got %v", err)
+	}
+	if _, _, err := Race(context.Background(), nil, fanOutFn); !errors.Is(err, ErrNoDrivers) {
+		t.Fatalf("expected %v, got %v", ErrNoDrivers, err)
+	}
+}
+
+func TestRaceNotRun(t *testing.T) {
+	drivers := Drivers{{Name: "fast", Protocol: "redfish"}, {Name: "slow", Protocol: "web"}, {Name: "fails", Protocol: "ipmi"}}
+	winner, results, err := Race(context.Background(), drivers, fanOutFn, WithParallelism(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if winner.Driver.Name != "fast" {
+		t.Fatalf("expected drivers to start in order and fast to win, got %+v", winner)
+	}
+	for _, res := range results[1:] {
+		if !errors.Is(res.Err, ErrNotRun) {
+			t.Fatalf("expected %v to not run once a winner was known, got %v", res.Driver.Name, res.Err)
+		}
+	}
+}
+
+func TestRaceLosersNotRecorded(t *testing.T) {
+	recorder := NewOutcomeRecorder()
+	breakers := NewBreakers(WithFailureThreshold(1))
+	drivers := Drivers{{Name: "slow", Protocol: "web"}, {Name: "fast", Protocol: "redfish"}}
+
+	if _, _, err := Race(context.Background(), drivers, fanOutFn, WithOutcomeRecorder(recorder, "bmc"), WithBreakers(breakers)); err != nil {
+		t.Fatal(err)
+	}
+	if got := breakers.State(drivers[0]); got != BreakerClosed {
+		t.Fatalf("expected the canceled loser's circuit to stay closed, got %v", got)
+	}
+	if stats, ok := recorder.Stats("bmc", drivers[0]); ok && stats.Failures != 0 {
+		t.Fatalf("expected no failure recorded for the canceled loser, got %+v", stats)
+	}
+	if stats, _ := recorder.Stats("bmc", drivers[1]); stats.Successes == 0 {
+		t.Fatalf("expected the winner's success to be recorded, got %+v", stats)
+	}
+}
+
+func TestQuorum(t *testing.T) {
+	drivers := Drivers{
+		{Name: "redfish", Protocol: "redfish"},
+		{Name: "ipmitool", Protocol: "ipmi"},
+		{Name: "webui", Protocol: "web"},
+		{Name: "broken", Protocol: "web"},
+	}
+	states := map[string]string{"redfish": "on", "ipmitool": "off", "webui": "on"}
+	fn := func(_ context.Context, d *Driver) (string, error) {
+		if state, ok := states[d.Name]; ok {
+			return state, nil
+		}
+		return "", errDriverFailed
+	}
+	equal := func(a, b string) bool { return a == b }
+
+	got, results, err := Quorum(context.Background(), drivers, 2, fn, equal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "on" {
+		t.Fatalf("expected the quorum to agree on on, got %v", got)
+	}
+	if len(results) != 4 {
+		t.Fatalf("expected a result per driver, got %d", len(results))
+	}
+
+	_, _, err = Quorum(context.Background(), drivers, 3, fn, equal)
+	if !errors.Is(err, ErrNoQuorum) || !errors.Is(err, errDriverFailed) {
+		t.Fatalf("expected %v wrapping the driver errors, got %v", ErrNoQuorum, err)
+	}
+}
@@ -0,0 +1,181 @@
+package registrar
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// DefaultHalfLife is how long it takes a recorded outcome to lose half its weight when no half-life is set.
+const DefaultHalfLife = 10 * time.Minute
+
+// RecorderOption for setting optional OutcomeRecorder values.
+type RecorderOption func(*OutcomeRecorder)
+
+// OutcomeRecorder records the success, failure and latency of driver calls per target.
+// A target is a caller supplied key, for example the host a registry is used against.
+// Older outcomes carry exponentially less weight, so recent behavior dominates the stats.
+type OutcomeRecorder struct {
+	mu       sync.Mutex
+	halfLife time.Duration
+	now      func() time.Time
+	stats    map[driverKey]*outcomeStats
+}
+
+// DriverStats holds the decayed outcome statistics of a driver for a target.
+type DriverStats struct {
+	// Successes and Failures are the decayed counts of recorded outcomes.
+	Successes float64
+	Failures  float64
+	// SuccessRate is the smoothed success rate, from 0 to 1. A driver without outcomes has a rate of 0.5.
+	SuccessRate float64
+	// Latency is the decayed average latency of successful calls. It is 0 without successful calls.
+	Latency time.Duration
+	// LastSeen is when the last outcome was recorded.
+	LastSeen time.Time
+}
+
+// outcomeStats holds the decayed sums for a driver, as of updated.
+type outcomeStats struct {
+	successes  float64
+	failures   float64
+	latencySum float64
+	updated    time.Time
+	// recorded is when the last outcome was recorded. Unlike updated, reads do not move it.
+	recorded time.Time
+}
+
+// WithHalfLife sets how long it takes a recorded outcome to lose half its weight.
+func WithHalfLife(halfLife time.Duration) RecorderOption {
+	return func(args *OutcomeRecorder) { args.halfLife = halfLife }
+}
+
+// NewOutcomeRecorder returns a new driver outcome recorder.
+func NewOutcomeRecorder(opts ...RecorderOption) *OutcomeRecorder {
+	defaultRecorder := &OutcomeRecorder{
+		halfLife: DefaultHalfLife,
+		now:      time.Now,
+		stats:    make(map[driverKey]*outcomeStats),
+	}
+	for _, opt := range opts {
+		opt(defaultRecorder)
+	}
+
+	return defaultRecorder
+}
+
+// Record records the outcome of calling a driver for a target. A nil err is a success.
+func (o *OutcomeRecorder) Record(target string, reg *Driver, err error, latency time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	key := driverKey{target: target, name: reg.Name, protocol: reg.Protocol}
+	st, ok := o.stats[key]
+	if !ok {
+		st = &outcomeStats{}
+		o.stats[key] = st
+	}
+	o.decay(st)
+	st.recorded = st.updated
+	if err != nil {
+		st.failures++
+		return
+	}
+	st.successes++
+	st.latencySum += latency.Seconds()
+}
+
+// Stats returns the outcome statistics of a driver for a target.
+// The bool is false when no outcome has been recorded.
+func (o *OutcomeRecorder) Stats(target string, reg *Driver) (DriverStats, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	st, ok := o.stats[driverKey{target: target, name: reg.Name, protocol: reg.Protocol}]
+	if !ok {
+		return DriverStats{SuccessRate: successRate(0, 0)}, false
+	}
+	o.decay(st)
+	stats := DriverStats{
+		Successes:   st.successes,
+		Failures:    st.failures,
+		SuccessRate: successRate(st.successes, st.failures),
+		LastSeen:    st.recorded,
+	}
+	if st.successes > 0 {
+		stats.Latency = time.Duration(st.latencySum / st.successes * float64(time.Second))
+	}
+	return stats, true
+}
+
+// Reset removes every recorded outcome for a target.
+func (o *OutcomeRecorder) Reset(target string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for key := range o.stats {
+		if key.target == target {
+			delete(o.stats, key)
+		}
+	}
+}
+
+// HealthScorer scores a driver by its smoothed success rate for a target, see Rank.
+func (o *OutcomeRecorder) HealthScorer(target string) Scorer {
+	return func(d *Driver) float64 {
+		stats, _ := o.Stats(target, d)
+		return stats.SuccessRate
+	}
+}
+
+// LatencyScorer scores a driver by the negated average latency of its successful calls
+// for a target, see Rank. Drivers without successful calls score lowest.
+func (o *OutcomeRecorder) LatencyScorer(target string) Scorer {
+	return func(d *Driver) float64 {
+		stats, _ := o.Stats(target, d)
+		if stats.Successes == 0 {
+			return math.Inf(-1)
+		}
+		return -stats.Latency.Seconds()
+	}
+}
+
+// decay brings the sums of st forward to now.
+func (o *OutcomeRecorder) decay(st *outcomeStats) {
+	now := o.now()
+	if !st.updated.IsZero() && o.halfLife > 0 {
+		factor := math.Pow(0.5, float64(now.Sub(st.updated))/float64(o.halfLife))
+		st.successes *= factor
+		st.failures *= factor
+		st.latencySum *= factor
+	}
+	st.updated = now
+}
+
+// successRate returns the success rate smoothed towards 0.5, so a few outcomes do not dominate.
+func successRate(successes, failures float64) float64 {
+	return (successes + 1) / (successes + failures + 2)
+}
+
+// PreferHealthy returns the drivers ordered by their success rate for a target, most successful first.
+// Drivers without recorded outcomes rank as if half their calls succeeded.
+func (d Drivers) PreferHealthy(recorder *OutcomeRecorder, target string) Drivers {
+	return d.Rank(recorder.HealthScorer(target))
+}
+
+// PreferFastest returns the drivers ordered by the average latency of their successful calls
+// for a target, fastest first. Drivers without successful calls are moved to the end.
+func (d Drivers) PreferFastest(recorder *OutcomeRecorder, target string) Drivers {
+	return d.Rank(recorder.LatencyScorer(target))
+}
+
+// PreferHealthy does the actual work of ordering drivers by their success rate for a target.
+func (r *Registry) PreferHealthy(recorder *OutcomeRecorder, target string) Drivers {
+	final := r.active().PreferHealthy(recorder, target)
+	r.logOrdered("PreferHealthy", target, final)
+	return final
+}
+
+// PreferFastest does the actual work of ordering drivers by their latency for a target.
+func (r *Registry) PreferFastest(recorder *OutcomeRecorder, target string) Drivers {
+	final := r.active().PreferFastest(recorder, target)
+	r.logOrdered("PreferFastest", target, final)
+	return final
+}
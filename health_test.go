@@ -0,0 +1,107 @@
+package registrar
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOutcomeRecorderDecay(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	rec := NewOutcomeRecorder(WithHalfLife(time.Minute))
+	rec.now = clock.Now
+	d := &Driver{Name: "dell", Protocol: "web"}
+
+	if stats, ok := rec.Stats("host", d); ok || stats.SuccessRate != 0.5 {
+		t.Fatalf("expected no stats and a neutral success rate, got %+v", stats)
+	}
+	rec.Record("host", d, errDriverFailed, time.Second)
+	rec.Record("host", d, errDriverFailed, time.Second)
+	clock.now = clock.now.Add(time.Minute)
+	rec.Record("host", d, nil, 2*time.Second)
+	recorded := clock.now
+	clock.now = clock.now.Add(time.Minute)
+
+	stats, ok := rec.Stats("host", d)
+	if !ok {
+		t.Fatal("expected stats")
+	}
+	// the failures are a half-life older than the success, so each weighs half as much.
+	if math.Abs(stats.Failures-0.5) > 1e-9 || math.Abs(stats.Successes-0.5) > 1e-9 {
+		t.Fatalf("expected decayed counts of 0.5 and 0.5, got %+v", stats)
+	}
+	if !stats.LastSeen.Equal(recorded) {
+		t.Fatalf("expected last seen to be when the success was recorded, %v, got %v", recorded, stats.LastSeen)
+	}
+	clock.now = clock.now.Add(time.Minute)
+	if stats, _ := rec.Stats("host", d); !stats.LastSeen.Equal(recorded) {
+		t.Fatalf("expected reading stats to leave last seen at %v, got %v", recorded, stats.LastSeen)
+	}
+	if math.Abs(stats.SuccessRate-0.5) > 1e-9 {
+		t.Fatalf("expected a success rate of 0.5, got %v", stats.SuccessRate)
+	}
+	if stats.Latency != 2*time.Second {
+		t.Fatalf("expected only successful calls to count towards latency, got %v", stats.Latency)
+	}
+
+	if _, ok := rec.Stats("other", d); ok {
+		t.Fatal("expected targets to be recorded separately")
+	}
+	rec.Reset("host")
+	if _, ok := rec.Stats("host", d); ok {
+		t.Fatal("expected stats to be reset")
+	}
+}
+
+func TestPreferHealthyAndFastest(t *testing.T) {
+	rec := NewOutcomeRecorder()
+	rg := NewRegistry()
+	rg.Register("flaky", "web", nil, nil, nil)
+	rg.Register("unknown", "web", nil, nil, nil)
+	rg.Register("slow", "ipmi", nil, nil, nil)
+	rg.Register("fast", "redfish", nil, nil, nil)
+	drivers := rg.Snapshot()
+
+	for i := 0; i < 3; i++ {
+		rec.Record("host", drivers[0], errDriverFailed, time.Millisecond)
+		rec.Record("host", drivers[2], nil, time.Second)
+		rec.Record("host", drivers[3], nil, 10*time.Millisecond)
+	}
+	rec.Record("host", drivers[3], errDriverFailed, time.Millisecond)
+
+	if diff := cmp.Diff(rg.PreferHealthy(rec, "host").Names(), []string{"slow", "fast", "unknown", "flaky"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if diff := cmp.Diff(rg.PreferFastest(rec, "host").Names(), []string{"fast", "slow", "flaky", "unknown"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if diff := cmp.Diff(rg.Query().Using("web").PreferHealthy(rec, "host").Drivers().Names(), []string{"unknown", "flaky"}); diff != "" {
+		t.Fatal(diff)
+	}
+	// a target without history keeps the original order.
+	if diff := cmp.Diff(rg.Query().PreferFastest(rec, "other").Drivers().Names(), []string{"flaky", "unknown", "slow", "fast"}); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestFirstSuccessRecordsOutcomes(t *testing.T) {
+	rec := NewOutcomeRecorder()
+	drivers := Drivers{{Name: "dell", Protocol: "web"}, {Name: "smc", Protocol: "web"}}
+	winner, err := FirstSuccess(context.Background(), drivers, func(_ context.Context, d *Driver) error {
+		if d.Name == "dell" {
+			return errDriverFailed
+		}
+		return nil
+	}, WithOutcomeRecorder(rec, "host"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the next operation starts with the driver that worked.
+	if diff := cmp.Diff(drivers.PreferHealthy(rec, "host"), Drivers{winner, drivers[0]}); diff != "" {
+		t.Fatal(diff)
+	}
+}
@@ -0,0 +1,89 @@
+package registrar
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Hedge calls fn for the drivers in order, starting with the first. Each time delay passes
+// without a success, or as soon as a call fails, the next driver is also started. The first
+// driver to succeed wins and the context passed to the outstanding calls is canceled.
+// WithParallelism bounds the number of calls in flight. Hedge waits for every started call to return.
+// All results are returned, in driver order, with ErrNotRun for drivers that were never started.
+// When no driver succeeds the error is a DriverErrors, or ErrNoDrivers when there are no drivers.
+func Hedge[T any](ctx context.Context, drivers Drivers, delay time.Duration, fn func(context.Context, *Driver) (T, error), opts ...ExecOption) (Result[T], []Result[T], error) {
+	cfg := newExecConfig(opts...)
+	var todo Drivers
+	for _, elem := range drivers {
+		if elem != nil {
+			todo = append(todo, elem)
+		}
+	}
+	if len(todo) == 0 {
+		return Result[T]{}, nil, ErrNoDrivers
+	}
+
+	parentDone := ctx.Done()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type indexed struct {
+		num int
+		res Result[T]
+	}
+	done := make(chan indexed, len(todo))
+	results := make([]Result[T], len(todo))
+	var next, inFlight int
+	var winner *Result[T]
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	start := func() {
+		if winner != nil || next >= len(todo) || (cfg.parallelism > 0 && inFlight >= cfg.parallelism) || ctx.Err() != nil {
+			return
+		}
+		go func(reg *Driver, num int) {
+			done <- indexed{num: num, res: callValue(ctx, cfg, reg, fn)}
+		}(todo[next], next)
+		next++
+		inFlight++
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(delay)
+	}
+
+	start()
+	for inFlight > 0 {
+		select {
+		case elem := <-done:
+			inFlight--
+			results[elem.num] = elem.res
+			if elem.res.Err == nil && winner == nil {
+				winner = &results[elem.num]
+				cancel()
+				continue
+			}
+			start()
+		case <-timer.C:
+			start()
+		case <-parentDone:
+			parentDone = nil
+			cancel()
+		}
+	}
+	for num := next; num < len(todo); num++ {
+		err := ErrNotRun
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = fmt.Errorf("%w: %w", ErrNotRun, ctxErr)
+		}
+		results[num] = Result[T]{Driver: todo[num], Err: err}
+	}
+	if winner == nil {
+		return Result[T]{}, results, resultErrors(results)
+	}
+
+	return *winner, results, nil
+}
@@ -0,0 +1,119 @@
+package registrar
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// hedgeFn answers after the delay configured for a driver, or fails for drivers without one.
+func hedgeFn(delays map[string]time.Duration, started *[]string, mu *sync.Mutex) func(context.Context, *Driver) (string, error) {
+	return func(ctx context.Context, d *Driver) (string, error) {
+		mu.Lock()
+		*started = append(*started, d.Name)
+		mu.Unlock()
+		delay, ok := delays[d.Name]
+		if !ok {
+			return "", errDriverFailed
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(delay):
+			return d.Name, nil
+		}
+	}
+}
+
+func TestHedge(t *testing.T) {
+	drivers := Drivers{{Name: "primary", Protocol: "redfish"}, {Name: "secondary", Protocol: "ipmi"}, {Name: "tertiary", Protocol: "web"}}
+	testCases := map[string]struct {
+		delays      map[string]time.Duration
+		opts        []ExecOption
+		wantWinner  string
+		wantStarted []string
+	}{
+		"primary answers within the hedge delay": {
+			delays:      map[string]time.Duration{"primary": 0, "secondary": 0, "tertiary": 0},
+			wantWinner:  "primary",
+			wantStarted: []string{"primary"},
+		},
+		"slow primary is hedged": {
+			delays:      map[string]time.Duration{"primary": time.Second, "secondary": 0, "tertiary": 0},
+			wantWinner:  "secondary",
+			wantStarted: []string{"primary", "secondary"},
+		},
+		"failure starts the next driver immediately": {
+			delays:      map[string]time.Duration{"secondary": 0},
+			wantWinner:  "secondary",
+			wantStarted: []string{"primary", "secondary"},
+		},
+		"max in flight": {
+			delays:      map[string]time.Duration{"primary": 200 * time.Millisecond, "secondary": 0},
+			opts:        []ExecOption{WithParallelism(1)},
+			wantWinner:  "primary",
+			wantStarted: []string{"primary"},
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			var mu sync.Mutex
+			var started []string
+			winner, results, err := Hedge(context.Background(), drivers, 50*time.Millisecond, hedgeFn(tc.delays, &started, &mu), tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if winner.Driver.Name != tc.wantWinner || winner.Value != tc.wantWinner {
+				t.Fatalf("expected %v to win, got %+v", tc.wantWinner, winner)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if diff := cmp.Diff(started, tc.wantStarted); diff != "" {
+				t.Fatal(diff)
+			}
+			if len(results) != len(drivers) {
+				t.Fatalf("expected a result per driver, got %d", len(results))
+			}
+			if !errors.Is(results[2].Err, ErrNotRun) {
+				t.Fatalf("expected the last driver to not run, got %v", results[2].Err)
+			}
+		})
+	}
+}
+
+func TestHedgeAllFail(t *testing.T) {
+	var mu sync.Mutex
+	var started []string
+	drivers := Drivers{{Name: "one", Protocol: "web"}, {Name: "two", Protocol: "web"}}
+	_, results, err := Hedge(context.Background(), drivers, time.Minute, hedgeFn(nil, &started, &mu))
+	var derrs DriverErrors
+	if !errors.As(err, &derrs) || len(derrs) != 2 {
+		t.Fatalf("expected both drivers to fail, got %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected a result per driver, got %d", len(results))
+	}
+	if _, _, err := Hedge(context.Background(), nil, time.Minute, hedgeFn(nil, &started, &mu)); !errors.Is(err, ErrNoDrivers) {
+		t.Fatalf("expected %v, got %v", ErrNoDrivers, err)
+	}
+}
+
+func TestHedgeCanceled(t *testing.T) {
+	var mu sync.Mutex
+	var started []string
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	drivers := Drivers{{Name: "one", Protocol: "web"}, {Name: "two", Protocol: "web"}}
+	_, results, err := Hedge(ctx, drivers, time.Minute, hedgeFn(map[string]time.Duration{"one": time.Minute, "two": 0}, &started, &mu))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline to be exceeded, got %v", err)
+	}
+	if !errors.Is(results[1].Err, ErrNotRun) {
+		t.Fatalf("expected the second driver to not run, got %v", results[1].Err)
+	}
+}
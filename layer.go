@@ -0,0 +1,69 @@
+package registrar
+
+// LayeredDriver is a driver along with the registry layer it came from, see Layered.
+type LayeredDriver struct {
+	*Driver
+	// Depth is 0 for drivers of the registry itself, 1 for drivers of its parent, and so on.
+	Depth int
+	// Source is the registry the driver is registered in.
+	Source *Registry
+}
+
+// WithParent sets the parent registry. The registry queries see the drivers of the parent,
+// and its ancestors, with drivers of the registry overriding those with the same name and protocol.
+func WithParent(parent *Registry) Option {
+	return func(args *Registry) { args.parent = parent }
+}
+
+// Parent returns the parent registry, or nil for a registry without one.
+func (r *Registry) Parent() *Registry {
+	return r.parent
+}
+
+// Child returns a new registry whose parent is r. It uses the Logger and Config of r unless opts set them.
+func (r *Registry) Child(opts ...Option) *Registry {
+	return NewRegistry(append([]Option{WithLogger(r.Logger), WithConfig(r.Config), WithParent(r)}, opts...)...)
+}
+
+// Layered returns the drivers the registry queries see, including disabled ones, along with the layer
+// each came from. The drivers of the parent come first, in order, with any driver overridden by
+// a driver of the registry, by name and protocol matched case insensitively, replaced in place.
+// Drivers only registered in the registry follow, in order. Overriding a driver with a
+// disabled one hides it from queries.
+func (r *Registry) Layered() []LayeredDriver {
+	var layered []LayeredDriver
+	if r.parent != nil {
+		layered = r.parent.Layered()
+		for idx := range layered {
+			layered[idx].Depth++
+		}
+	}
+	for _, elem := range r.Snapshot() {
+		if elem == nil {
+			continue
+		}
+		layered = override(layered, LayeredDriver{Driver: elem, Source: r})
+	}
+	return layered
+}
+
+// override replaces the first inherited driver with the name and protocol of d by d, removing any
+// other inherited ones, or appends d when there is none. Drivers of the same layer are never replaced.
+func override(layered []LayeredDriver, d LayeredDriver) []LayeredDriver {
+	result := layered[:0]
+	replaced := false
+	for _, elem := range layered {
+		if elem.Depth == 0 || !isDriver(elem.Driver, d.Name, d.Protocol) {
+			result = append(result, elem)
+			continue
+		}
+		if !replaced {
+			result = append(result, d)
+			replaced = true
+		}
+	}
+	if !replaced {
+		result = append(result, d)
+	}
+	return result
+}
@@ -0,0 +1,70 @@
+package registrar
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// layerIDs returns a "name/protocol@depth" identifier for each driver.
+func layerIDs(layered []LayeredDriver) []string {
+	ids := make([]string, 0, len(layered))
+	for _, elem := range layered {
+		ids = append(ids, elem.Name+"/"+elem.Protocol+"@"+strconv.Itoa(elem.Depth))
+	}
+	return ids
+}
+
+func TestLayered(t *testing.T) {
+	base := NewRegistry()
+	base.Register("gofish", "redfish", Features{FeaturePowerSet}, nil, &driverOne{name: "base"})
+	base.Register("ipmitool", "ipmi", Features{FeaturePowerSet}, nil, &driverOne{isCompatible: true})
+	base.Register("smc", "ipmi", Features{FeaturePowerSet}, nil, &driverOne{})
+
+	tenant := base.Child()
+	tenant.Register("custom", "redfish", Features{FeaturePowerSet}, nil, &driverOne{isCompatible: true})
+	tenant.Register("GOFISH", "Redfish", Features{FeaturePowerSet}, nil, &driverOne{name: "tenant"})
+	tenant.Register("smc", "ipmi", nil, nil, &driverOne{})
+	_ = tenant.Disable("smc", "ipmi")
+
+	team := tenant.Child()
+	team.Register("team", "ipmi", nil, nil, &driverOne{})
+
+	tests := map[string]struct {
+		registry *Registry
+		want     []string
+	}{
+		"base":   {registry: base, want: []string{"gofish/redfish@0", "ipmitool/ipmi@0", "smc/ipmi@0"}},
+		"tenant": {registry: tenant, want: []string{"GOFISH/Redfish@0", "ipmitool/ipmi@1", "smc/ipmi@0", "custom/redfish@0"}},
+		"team":   {registry: team, want: []string{"GOFISH/Redfish@1", "ipmitool/ipmi@2", "smc/ipmi@1", "custom/redfish@1", "team/ipmi@0"}},
+	}
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(layerIDs(tc.registry.Layered()), tc.want); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+
+	if got := team.Layered()[1].Source; got != base {
+		t.Fatal("expected the source of an inherited driver to be the registry it is registered in")
+	}
+	if diff := cmp.Diff(tenant.Supports(FeaturePowerSet).Names(), []string{"GOFISH", "ipmitool", "custom"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if diff := cmp.Diff(team.Using("ipmi").Names(), []string{"ipmitool", "team"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if got := tenant.For("GOFISH")[0].DriverInterface.(*driverOne).name; got != "tenant" {
+		t.Fatalf("expected the tenant driver to override the base one, got %q", got)
+	}
+	if diff := cmp.Diff(tenant.FilterForCompatible(context.Background()).Names(), []string{"ipmitool", "custom"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if diff := cmp.Diff(base.Snapshot().Names(), []string{"gofish", "ipmitool", "smc"}); diff != "" {
+		t.Fatalf("expected the parent not to be affected by the child: %v", diff)
+	}
+}
@@ -0,0 +1,209 @@
+package registrar
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Opener allows implementations to define a method for opening a session,
+// for example logging in to a BMC, before they are used.
+type Opener interface {
+	Open(context.Context) error
+}
+
+// Closer allows implementations to define a method for closing a session opened by Open.
+type Closer interface {
+	Close(context.Context) error
+}
+
+// OpenOption for setting optional Open values.
+type OpenOption func(*openConfig)
+
+// openConfig holds the settings used when opening drivers.
+type openConfig struct {
+	// timeout bounds each individual driver's Open call.
+	timeout time.Duration
+}
+
+// WithOpenTimeout sets the maximum time a single driver's Open call may take.
+func WithOpenTimeout(timeout time.Duration) OpenOption {
+	return func(args *openConfig) { args.timeout = timeout }
+}
+
+// openCall is an Open of a single driver that is in progress.
+type openCall struct {
+	done chan struct{}
+	err  error
+}
+
+// Open concurrently opens the drivers that implement Opener and returns, in order, the drivers
+// that are ready for use. Drivers that do not implement Opener are ready without being opened.
+// Drivers that implement Opener or Closer are tracked by the registry, so Close can close them;
+// drivers the registry already tracks, including copies sharing a pointer implementation, are not
+// opened again, and drivers another Open call is opening are waited for rather than opened twice. When a driver fails to open the returned
+// error is a DriverErrors holding the error of every driver that failed.
+func (r *Registry) Open(ctx context.Context, drivers Drivers, opts ...OpenOption) (Drivers, error) {
+	cfg := &openConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	// claim, under the lock, the drivers this call opens so concurrent calls wait for them.
+	calls := make([]*openCall, len(drivers))
+	owned := make([]bool, len(drivers))
+	r.mu.Lock()
+	alreadyOpen := make(map[interface{}]struct{}, len(r.opened))
+	for _, elem := range r.opened {
+		alreadyOpen[lifecycleKey(elem)] = struct{}{}
+	}
+	for idx, elem := range drivers {
+		if elem == nil || !isLifecycle(elem) {
+			continue
+		}
+		key := lifecycleKey(elem)
+		if _, open := alreadyOpen[key]; open {
+			continue
+		}
+		if call, ok := r.opening[key]; ok {
+			calls[idx] = call
+			continue
+		}
+		if r.opening == nil {
+			r.opening = make(map[interface{}]*openCall)
+		}
+		calls[idx] = &openCall{done: make(chan struct{})}
+		owned[idx] = true
+		r.opening[key] = calls[idx]
+	}
+	r.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for idx, elem := range drivers {
+		if !owned[idx] {
+			continue
+		}
+		opener, ok := elem.DriverInterface.(Opener)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(o Opener, num int) {
+			defer wg.Done()
+			ctx := ctx
+			if cfg.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
+				defer cancel()
+			}
+			start := time.Now()
+			calls[num].err = o.Open(ctx)
+			r.logLifecycle("open", drivers[num], time.Since(start), calls[num].err)
+		}(opener, idx)
+	}
+	wg.Wait()
+
+	r.mu.Lock()
+	for idx, elem := range drivers {
+		if !owned[idx] {
+			continue
+		}
+		delete(r.opening, lifecycleKey(elem))
+		if calls[idx].err == nil {
+			r.opened = append(r.opened, elem)
+		}
+		close(calls[idx].done)
+	}
+	r.mu.Unlock()
+
+	var ready Drivers
+	var failed DriverErrors
+	for idx, elem := range drivers {
+		if elem == nil {
+			continue
+		}
+		var err error
+		switch call := calls[idx]; {
+		case call == nil:
+		case owned[idx]:
+			err = call.err
+		default:
+			// only the wait for a driver another Open call is opening gives up when ctx is done.
+			select {
+			case <-call.done:
+				err = call.err
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+		}
+		if err != nil {
+			failed = append(failed, &DriverError{Name: elem.Name, Protocol: elem.Protocol, Attempts: 1, Err: err})
+			continue
+		}
+		ready = append(ready, elem)
+	}
+	if len(failed) > 0 {
+		return ready, failed
+	}
+
+	return ready, nil
+}
+
+// Opened returns the drivers the registry tracks as open, in the order they were opened.
+func (r *Registry) Opened() Drivers {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	opened := make(Drivers, len(r.opened))
+	copy(opened, r.opened)
+	return opened
+}
+
+// Close closes every driver opened by Open, one at a time in the reverse order they were opened.
+// Drivers that do not implement Closer are only forgotten. Every driver is forgotten, even when
+// closing it fails. When a driver fails to close the returned error is a DriverErrors.
+func (r *Registry) Close(ctx context.Context) error {
+	r.mu.Lock()
+	opened := r.opened
+	r.opened = nil
+	r.mu.Unlock()
+
+	var failed DriverErrors
+	for idx := len(opened) - 1; idx >= 0; idx-- {
+		elem := opened[idx]
+		closer, ok := elem.DriverInterface.(Closer)
+		if !ok {
+			continue
+		}
+		start := time.Now()
+		err := closer.Close(ctx)
+		r.logLifecycle("close", elem, time.Since(start), err)
+		if err != nil {
+			failed = append(failed, &DriverError{Name: elem.Name, Protocol: elem.Protocol, Attempts: 1, Err: err})
+		}
+	}
+	if len(failed) > 0 {
+		return failed
+	}
+
+	return nil
+}
+
+// lifecycleKey identifies a driver's session. Pointer implementations are identified by the
+// implementation, so copies of a driver, for example made by Disable and Enable, share their session.
+// Other implementations are identified by the driver.
+func lifecycleKey(reg *Driver) interface{} {
+	if reflect.ValueOf(reg.DriverInterface).Kind() == reflect.Ptr {
+		return reg.DriverInterface
+	}
+	return reg
+}
+
+// isLifecycle reports whether a driver implements Opener or Closer.
+func isLifecycle(reg *Driver) bool {
+	switch reg.DriverInterface.(type) {
+	case Opener, Closer:
+		return true
+	default:
+		return false
+	}
+}
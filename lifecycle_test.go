@@ -0,0 +1,194 @@
+package registrar
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// sessionDriver is an Opener and Closer that records the order it was closed in.
+type sessionDriver struct {
+	name    string
+	openErr error
+	// openDelay is how long Open takes unless ctx is done first.
+	openDelay time.Duration
+	mu        *sync.Mutex
+	closed    *[]string
+	opens     int32
+}
+
+func (s *sessionDriver) Open(ctx context.Context) error {
+	atomic.AddInt32(&s.opens, 1)
+	select {
+	case <-time.After(s.openDelay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return s.openErr
+}
+
+func (s *sessionDriver) Close(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	*s.closed = append(*s.closed, s.name)
+	if s.name == "bad-close" {
+		return errDriverFailed
+	}
+	return nil
+}
+
+func TestOpenClose(t *testing.T) {
+	var mu sync.Mutex
+	var closed []string
+	session := func(name string) *sessionDriver { return &sessionDriver{name: name, mu: &mu, closed: &closed} }
+	failing := session("failing")
+	failing.openErr = errDriverFailed
+	hung := session("hung")
+	hung.openDelay = time.Minute
+
+	rg := NewRegistry()
+	rg.Register("one", "redfish", nil, nil, session("one"))
+	rg.Register("plain", "tcp", nil, nil, struct{}{})
+	rg.Register("failing", "ipmi", nil, nil, failing)
+	rg.Register("hung", "ipmi", nil, nil, hung)
+	rg.Register("bad-close", "ipmi", nil, nil, session("bad-close"))
+
+	start := time.Now()
+	ready, err := rg.Open(context.Background(), rg.Drivers, WithOpenTimeout(50*time.Millisecond))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected the hung driver to time out, opening took %v", elapsed)
+	}
+	if diff := cmp.Diff(ready.Names(), []string{"one", "plain", "bad-close"}); diff != "" {
+		t.Fatal(diff)
+	}
+	var derrs DriverErrors
+	if !errors.As(err, &derrs) {
+		t.Fatalf("expected DriverErrors, got %v", err)
+	}
+	if !errors.Is(derrs.For("failing")[0], errDriverFailed) {
+		t.Fatalf("expected the failing driver's error, got %v", derrs.For("failing"))
+	}
+	if !errors.Is(derrs.For("hung")[0], context.DeadlineExceeded) {
+		t.Fatalf("expected the hung driver to time out, got %v", derrs.For("hung"))
+	}
+	if diff := cmp.Diff(rg.Opened().Names(), []string{"one", "bad-close"}); diff != "" {
+		t.Fatal(diff)
+	}
+
+	// opening again does not reopen drivers that are already open.
+	if _, err := rg.Open(context.Background(), rg.For("one")); err != nil {
+		t.Fatal(err)
+	}
+	if opens := atomic.LoadInt32(&rg.For("one")[0].DriverInterface.(*sessionDriver).opens); opens != 1 {
+		t.Fatalf("expected one open, got %d", opens)
+	}
+
+	err = rg.Close(context.Background())
+	if !errors.Is(err, errDriverFailed) {
+		t.Fatalf("expected the close error to be reported, got %v", err)
+	}
+	if diff := cmp.Diff(closed, []string{"bad-close", "one"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if got := rg.Opened(); len(got) != 0 {
+		t.Fatalf("expected no open drivers after Close, got %v", got.Names())
+	}
+	if err := rg.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenConcurrent(t *testing.T) {
+	var mu sync.Mutex
+	var closed []string
+	session := &sessionDriver{name: "one", openDelay: 20 * time.Millisecond, mu: &mu, closed: &closed}
+	rg := NewRegistry()
+	rg.Register("one", "redfish", nil, nil, session)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ready, err := rg.Open(context.Background(), rg.Snapshot())
+			if err != nil || len(ready) != 1 {
+				t.Errorf("expected the driver to be ready, got %v, %v", ready.Names(), err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if opens := atomic.LoadInt32(&session.opens); opens != 1 {
+		t.Fatalf("expected the driver to be opened once, got %d", opens)
+	}
+	if got := len(rg.Opened()); got != 1 {
+		t.Fatalf("expected the driver to be tracked once, got %d", got)
+	}
+	if err := rg.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(closed, []string{"one"}); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestOpenAfterDisableEnable(t *testing.T) {
+	var mu sync.Mutex
+	var closed []string
+	session := &sessionDriver{name: "one", mu: &mu, closed: &closed}
+	rg := NewRegistry()
+	rg.Register("one", "redfish", nil, nil, session)
+
+	if _, err := rg.Open(context.Background(), rg.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if err := rg.Disable("one", "redfish"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rg.Enable("one", "redfish"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rg.Open(context.Background(), rg.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if err := rg.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if opens := atomic.LoadInt32(&session.opens); opens != 1 {
+		t.Fatalf("expected the session to be opened once, got %d", opens)
+	}
+	if diff := cmp.Diff(closed, []string{"one"}); diff != "" {
+		t.Fatalf("expected the session to be closed once: %v", diff)
+	}
+}
+
+// stubbornDriver is an Opener that ignores ctx and always opens.
+type stubbornDriver struct{}
+
+func (stubbornDriver) Open(_ context.Context) error { return nil }
+
+func TestOpenCanceledContext(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		rg := NewRegistry()
+		rg.Register("stubborn", "ipmi", nil, nil, stubbornDriver{})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		ready, err := rg.Open(ctx, rg.Snapshot())
+		if err != nil {
+			t.Fatalf("expected a driver that opened to be ready, got %v", err)
+		}
+		if diff := cmp.Diff(ready.Names(), []string{"stubborn"}); diff != "" {
+			t.Fatal(diff)
+		}
+		if got := len(rg.Opened()); got != 1 {
+			t.Fatalf("expected the driver to be tracked, got %d", got)
+		}
+	}
+}
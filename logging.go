@@ -0,0 +1,69 @@
+package registrar
+
+import "time"
+
+// logFiltered logs, at V(1), which drivers a filter kept and which it dropped.
+func (r *Registry) logFiltered(filter string, criteria interface{}, in, out Drivers) {
+	log := r.Logger.V(1)
+	if !log.Enabled() {
+		return
+	}
+	kept := make(map[*Driver]struct{}, len(out))
+	for _, elem := range out {
+		kept[elem] = struct{}{}
+	}
+	var dropped Drivers
+	for _, elem := range in {
+		if _, ok := kept[elem]; !ok {
+			dropped = append(dropped, elem)
+		}
+	}
+	log.Info("filtered drivers", "filter", filter, "criteria", criteria, "kept", driverIDs(out), "dropped", driverIDs(dropped))
+}
+
+// logOrdered logs, at V(1), the driver order an ordering produced.
+func (r *Registry) logOrdered(ordering string, criteria interface{}, out Drivers) {
+	log := r.Logger.V(1)
+	if !log.Enabled() {
+		return
+	}
+	log.Info("ordered drivers", "ordering", ordering, "criteria", criteria, "order", driverIDs(out))
+}
+
+// logProbe logs, at V(1), the outcome of a single driver's compatibility check.
+func (r *Registry) logProbe(res *CompatibilityResult) {
+	log := r.Logger.V(1)
+	if !log.Enabled() {
+		return
+	}
+	kv := []interface{}{"name", res.Name, "protocol", res.Protocol, "compatible", res.Compatible, "duration", res.Duration.Round(time.Microsecond).String(), "cached", res.Cached}
+	if res.Err != nil {
+		kv = append(kv, "reason", res.Err.Error())
+	}
+	log.Info("compatibility check", kv...)
+}
+
+// logLifecycle logs, at V(1), the outcome of opening or closing a single driver.
+func (r *Registry) logLifecycle(action string, reg *Driver, duration time.Duration, err error) {
+	log := r.Logger.V(1)
+	if !log.Enabled() {
+		return
+	}
+	kv := []interface{}{"action", action, "name", reg.Name, "protocol", reg.Protocol, "duration", duration.Round(time.Microsecond).String()}
+	if err != nil {
+		kv = append(kv, "error", err.Error())
+	}
+	log.Info("driver lifecycle", kv...)
+}
+
+// driverIDs returns a "name/protocol" identifier for each driver, for use in log output.
+func driverIDs(drivers Drivers) []string {
+	ids := make([]string, 0, len(drivers))
+	for _, elem := range drivers {
+		if elem == nil {
+			continue
+		}
+		ids = append(ids, elem.Name+"/"+elem.Protocol)
+	}
+	return ids
+}
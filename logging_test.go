@@ -0,0 +1,110 @@
+package registrar
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+)
+
+// recordingSink is a logr.LogSink that records log messages.
+type recordingSink struct {
+	mu     sync.Mutex
+	infos  []map[string]interface{}
+	errors []string
+}
+
+func (r *recordingSink) Init(logr.RuntimeInfo)                  {}
+func (r *recordingSink) Enabled(int) bool                       { return true }
+func (r *recordingSink) WithValues(...interface{}) logr.LogSink { return r }
+func (r *recordingSink) WithName(string) logr.LogSink           { return r }
+func (r *recordingSink) Info(_ int, msg string, kv ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry := map[string]interface{}{"msg": msg}
+	for i := 0; i+1 < len(kv); i += 2 {
+		entry[kv[i].(string)] = kv[i+1]
+	}
+	r.infos = append(r.infos, entry)
+}
+
+func (r *recordingSink) Error(_ error, msg string, _ ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, msg)
+}
+
+// find returns the recorded info entries with the given message.
+func (r *recordingSink) find(msg string) []map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var found []map[string]interface{}
+	for _, entry := range r.infos {
+		if entry["msg"] == msg {
+			found = append(found, entry)
+		}
+	}
+	return found
+}
+
+func TestLogging(t *testing.T) {
+	sink := &recordingSink{}
+	rg := NewRegistry(WithLogger(logr.New(sink)))
+	rg.Register("dell", "web", Features{FeaturePowerSet}, nil, &driverOne{isCompatible: true})
+	rg.Register("ipmitool", "ipmi", Features{FeatureUserCreate}, nil, &driverOne{isCompatible: false})
+	_ = rg.RegisterE("", "ipmi", nil, nil, nil)
+
+	if got := len(sink.find("driver registered")); got != 2 {
+		t.Fatalf("expected 2 registration logs, got %d", got)
+	}
+	if got := len(sink.find("driver registration rejected")); got != 1 {
+		t.Fatalf("expected 1 rejected registration log, got %d", got)
+	}
+
+	rg.Supports(FeaturePowerSet)
+	rg.Using("ipmi")
+	rg.For("smc")
+	filtered := sink.find("filtered drivers")
+	want := []struct{ kept, dropped []string }{
+		{kept: []string{"dell/web"}, dropped: []string{"ipmitool/ipmi"}},
+		{kept: []string{"ipmitool/ipmi"}, dropped: []string{"dell/web"}},
+		{kept: []string{}, dropped: []string{"dell/web", "ipmitool/ipmi"}},
+	}
+	if len(filtered) != len(want) {
+		t.Fatalf("expected %d filter logs, got %d", len(want), len(filtered))
+	}
+	for i, w := range want {
+		if diff := cmp.Diff(filtered[i]["kept"], w.kept); diff != "" {
+			t.Fatal(diff)
+		}
+		if diff := cmp.Diff(filtered[i]["dropped"], w.dropped); diff != "" {
+			t.Fatal(diff)
+		}
+	}
+
+	rg.PreferProtocol("ipmi")
+	rg.PreferDriver("dell")
+	ordered := sink.find("ordered drivers")
+	if len(ordered) != 2 {
+		t.Fatalf("expected 2 ordering logs, got %d", len(ordered))
+	}
+	if diff := cmp.Diff(ordered[0]["order"], []string{"ipmitool/ipmi", "dell/web"}); diff != "" {
+		t.Fatal(diff)
+	}
+
+	rg.FilterForCompatible(context.Background())
+	probes := sink.find("compatibility check")
+	if len(probes) != 2 {
+		t.Fatalf("expected 2 compatibility check logs, got %d", len(probes))
+	}
+	for _, entry := range probes {
+		if _, ok := entry["duration"]; !ok {
+			t.Fatalf("expected compatibility check log to include a duration: %v", entry)
+		}
+	}
+	if probes[1]["reason"] != ErrIncompatible.Error() {
+		t.Fatalf("expected the incompatible driver to log a reason, got %v", probes[1])
+	}
+}
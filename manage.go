@@ -0,0 +1,122 @@
+package registrar
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrDriverNotFound is returned when no driver with the given name and protocol is registered.
+var ErrDriverNotFound = errors.New("driver not found")
+
+// Unregister removes every driver with the name and protocol, matched case insensitively.
+// Drivers already returned by queries or Snapshot are not affected.
+func (r *Registry) Unregister(name, protocol string) error {
+	if err := r.swap(name, protocol, EventUnregistered, func(*Driver) *Driver { return nil }); err != nil {
+		return err
+	}
+	r.Logger.V(1).Info("driver unregistered", "name", name, "protocol", protocol)
+
+	return nil
+}
+
+// Replace swaps the first driver with the name and protocol, matched case insensitively,
+// for a new one built from the arguments, keeping its position in the registry.
+// Any other drivers with the name and protocol are removed.
+func (r *Registry) Replace(name, protocol string, features Features, metadata interface{}, driverInterface interface{}, opts ...DriverOption) error {
+	d := newDriver(name, protocol, features, metadata, driverInterface, opts...)
+	replaced := false
+	err := r.swap(name, protocol, EventReplaced, func(*Driver) *Driver {
+		if replaced {
+			return nil
+		}
+		replaced = true
+		return d
+	})
+	if err != nil {
+		return err
+	}
+	r.Logger.V(1).Info("driver replaced", "name", name, "protocol", protocol, "features", features)
+
+	return nil
+}
+
+// Disable marks every driver with the name and protocol, matched case insensitively, as disabled.
+// Disabled drivers stay registered but are skipped by the Registry queries until enabled again.
+func (r *Registry) Disable(name, protocol string) error {
+	return r.setDisabled(name, protocol, true)
+}
+
+// Enable clears the disabled mark of every driver with the name and protocol, matched case insensitively.
+func (r *Registry) Enable(name, protocol string) error {
+	return r.setDisabled(name, protocol, false)
+}
+
+// setDisabled does the actual work of disabling and enabling drivers.
+// The drivers are copied, not modified, so drivers already returned by queries are not affected.
+func (r *Registry) setDisabled(name, protocol string, disabled bool) error {
+	typ := EventEnabled
+	if disabled {
+		typ = EventDisabled
+	}
+	err := r.swap(name, protocol, typ, func(elem *Driver) *Driver {
+		if elem.Disabled == disabled {
+			return elem
+		}
+		d := *elem
+		d.Disabled = disabled
+		return &d
+	})
+	if err != nil {
+		return err
+	}
+	msg := "driver enabled"
+	if disabled {
+		msg = "driver disabled"
+	}
+	r.Logger.V(1).Info(msg, "name", name, "protocol", protocol)
+
+	return nil
+}
+
+// swap replaces, in a copy of the drivers, each driver with the name and protocol by the result of fn,
+// emitting typ for it. Drivers for which fn returns nil are removed, emitting EventUnregistered.
+// Drivers for which fn returns the same driver are kept without emitting an event.
+func (r *Registry) swap(name, protocol string, typ EventType, fn func(*Driver) *Driver) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	drivers := make(Drivers, 0, len(r.Drivers))
+	var found bool
+	for _, elem := range r.Drivers {
+		if !isDriver(elem, name, protocol) {
+			drivers = append(drivers, elem)
+			continue
+		}
+		found = true
+		switch d := fn(elem); d {
+		case nil:
+			r.emit(EventUnregistered, elem)
+		case elem:
+			drivers = append(drivers, d)
+		default:
+			drivers = append(drivers, d)
+			r.emit(typ, d)
+		}
+	}
+	if !found {
+		return notFound(name, protocol)
+	}
+	r.Drivers = drivers
+
+	return nil
+}
+
+// isDriver reports whether reg has the name and protocol, matched case insensitively.
+func isDriver(reg *Driver, name, protocol string) bool {
+	return reg != nil && strings.EqualFold(reg.Name, name) && strings.EqualFold(reg.Protocol, protocol)
+}
+
+// notFound returns ErrDriverNotFound for the name and protocol.
+func notFound(name, protocol string) error {
+	return fmt.Errorf("%w: %q (protocol %q)", ErrDriverNotFound, name, protocol)
+}
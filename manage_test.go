@@ -0,0 +1,101 @@
+package registrar
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnregister(t *testing.T) {
+	tests := map[string]struct {
+		name     string
+		protocol string
+		want     []string
+		err      error
+	}{
+		"removes every match":        {name: "dup", protocol: "tcp", want: []string{"one", "two"}},
+		"matched case insensitively": {name: "ONE", protocol: "TCP", want: []string{"dup", "dup", "two"}},
+		"protocol must match":        {name: "one", protocol: "udp", want: []string{"one", "dup", "dup", "two"}, err: ErrDriverNotFound},
+		"not found":                  {name: "three", protocol: "tcp", want: []string{"one", "dup", "dup", "two"}, err: ErrDriverNotFound},
+	}
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			rg := NewRegistry()
+			rg.Register("one", "tcp", nil, nil, &driverOne{})
+			rg.Register("dup", "tcp", nil, nil, &driverOne{})
+			rg.Register("dup", "tcp", nil, nil, &driverOne{})
+			rg.Register("two", "tcp", nil, nil, &driverOne{})
+			before := rg.Snapshot()
+
+			err := rg.Unregister(tc.name, tc.protocol)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+			if diff := cmp.Diff(rg.Snapshot().Names(), tc.want); diff != "" {
+				t.Fatal(diff)
+			}
+			if len(before) != 4 {
+				t.Fatal("expected earlier snapshots not to be affected")
+			}
+		})
+	}
+}
+
+func TestReplace(t *testing.T) {
+	rg := NewRegistry()
+	rg.Register("one", "tcp", nil, nil, &driverOne{name: "old"})
+	rg.Register("two", "tcp", nil, nil, &driverOne{})
+	rg.Register("one", "tcp", nil, nil, &driverOne{name: "older"})
+
+	if err := rg.Replace("one", "tcp", Features{FeaturePowerSet}, nil, &driverOne{name: "new"}, WithPriority(3)); err != nil {
+		t.Fatal(err)
+	}
+	drivers := rg.Snapshot()
+	if diff := cmp.Diff(drivers.Names(), []string{"one", "two"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if len(drivers) != 2 || drivers[0].DriverInterface.(*driverOne).name != "new" || drivers[0].Priority != 3 {
+		t.Fatalf("expected the first driver to be replaced in place and the duplicate removed, got %+v", drivers)
+	}
+	if err := rg.Replace("three", "tcp", nil, nil, &driverOne{}); !errors.Is(err, ErrDriverNotFound) {
+		t.Fatalf("expected %v, got %v", ErrDriverNotFound, err)
+	}
+}
+
+func TestDisableEnable(t *testing.T) {
+	rg := NewRegistry()
+	rg.Register("one", "tcp", Features{FeaturePowerSet}, nil, &driverOne{})
+	rg.Register("two", "tcp", Features{FeaturePowerSet}, nil, &driverOne{})
+	queried := rg.Supports(FeaturePowerSet)
+
+	if err := rg.Disable("one", "tcp"); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(rg.Supports(FeaturePowerSet).Names(), []string{"two"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if diff := cmp.Diff(rg.Query().Using("tcp").Drivers().Names(), []string{"two"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if got := len(rg.GetDriverInterfaces()); got != 1 {
+		t.Fatalf("expected 1 driver interface, got %d", got)
+	}
+	if diff := cmp.Diff(rg.Snapshot().Names(), []string{"one", "two"}); diff != "" {
+		t.Fatalf("expected disabled drivers to stay registered: %v", diff)
+	}
+	if queried[0].Disabled {
+		t.Fatal("expected drivers returned by earlier queries not to be modified")
+	}
+
+	if err := rg.Enable("one", "tcp"); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(rg.Supports(FeaturePowerSet).Names(), []string{"one", "two"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if err := rg.Disable("three", "tcp"); !errors.Is(err, ErrDriverNotFound) {
+		t.Fatalf("expected %v, got %v", ErrDriverNotFound, err)
+	}
+}
@@ -0,0 +1,166 @@
+package registrar
+
+import "context"
+
+// Query is an immutable, reusable chain of filters and orderings over drivers.
+// Every method returns a new Query, leaving the receiver and the underlying
+// Registry untouched, so a partially built Query can be shared and extended.
+// The chain is only run when Drivers, Apply or GetDriverInterfaces is called.
+type Query struct {
+	registry *Registry
+	steps    []queryStep
+}
+
+// queryStep is a single operation of a Query. It runs against a Registry holding the current drivers.
+type queryStep func(*Registry) Drivers
+
+// NewQuery returns an empty Query that is not bound to a Registry.
+// Use On to bind it or Apply to run it against a set of drivers.
+func NewQuery() *Query {
+	return &Query{}
+}
+
+// Query returns an empty Query bound to the registry.
+func (r *Registry) Query() *Query {
+	return &Query{registry: r}
+}
+
+// On returns a copy of the query bound to a registry.
+func (q *Query) On(r *Registry) *Query {
+	return &Query{registry: r, steps: q.steps}
+}
+
+// Supports adds a step keeping only drivers that support all of the features.
+func (q *Query) Supports(features ...Feature) *Query {
+	return q.with(func(r *Registry) Drivers { return r.Supports(features...) })
+}
+
+// SupportsAny adds a step keeping only drivers that support at least one of the features.
+func (q *Query) SupportsAny(features ...Feature) *Query {
+	return q.with(func(r *Registry) Drivers { return r.SupportsAny(features...) })
+}
+
+// SupportsNone adds a step keeping only drivers that support none of the features.
+func (q *Query) SupportsNone(features ...Feature) *Query {
+	return q.with(func(r *Registry) Drivers { return r.SupportsNone(features...) })
+}
+
+// Satisfying adds a step keeping only drivers whose features satisfy the expression.
+func (q *Query) Satisfying(expr FeatureExpr) *Query {
+	return q.with(func(r *Registry) Drivers { return r.Satisfying(expr) })
+}
+
+// Using adds a step keeping only drivers for a protocol.
+func (q *Query) Using(proto string) *Query {
+	return q.with(func(r *Registry) Drivers { return r.Using(proto) })
+}
+
+// For adds a step keeping only drivers with a name.
+func (q *Query) For(driver string) *Query {
+	return q.with(func(r *Registry) Drivers { return r.For(driver) })
+}
+
+// Matching adds a step keeping only drivers whose labels match the selector.
+func (q *Query) Matching(sel Selector) *Query {
+	return q.with(func(r *Registry) Drivers { return r.Matching(sel) })
+}
+
+// PreferProtocol adds a step moving drivers for the protocols to the start.
+func (q *Query) PreferProtocol(protocols ...string) *Query {
+	return q.with(func(r *Registry) Drivers { return r.PreferProtocol(protocols...) })
+}
+
+// PreferDriver adds a step moving drivers with the names to the start.
+func (q *Query) PreferDriver(drivers ...string) *Query {
+	return q.with(func(r *Registry) Drivers { return r.PreferDriver(drivers...) })
+}
+
+// Rank adds a step ordering drivers by the sum of their scores, highest first.
+func (q *Query) Rank(scorers ...Scorer) *Query {
+	return q.with(func(r *Registry) Drivers { return r.Rank(scorers...) })
+}
+
+// PreferHealthy adds a step ordering drivers by their success rate for a target.
+func (q *Query) PreferHealthy(recorder *OutcomeRecorder, target string) *Query {
+	return q.with(func(r *Registry) Drivers { return r.PreferHealthy(recorder, target) })
+}
+
+// PreferFastest adds a step ordering drivers by their latency for a target.
+func (q *Query) PreferFastest(recorder *OutcomeRecorder, target string) *Query {
+	return q.with(func(r *Registry) Drivers { return r.PreferFastest(recorder, target) })
+}
+
+// SkipOpen adds a step dropping drivers whose circuit is open.
+func (q *Query) SkipOpen(breakers *Breakers) *Query {
+	return q.with(func(r *Registry) Drivers { return r.SkipOpen(breakers) })
+}
+
+// DemoteOpen adds a step moving drivers whose circuit is open to the end.
+func (q *Query) DemoteOpen(breakers *Breakers) *Query {
+	return q.with(func(r *Registry) Drivers { return r.DemoteOpen(breakers) })
+}
+
+// Compatible adds a step keeping only compatible drivers, see FilterForCompatible.
+// The check runs with ctx each time the query is run. Results are recorded on the bound
+// registry, which emits EventCompatibilityChanged as FilterForCompatible does.
+func (q *Query) Compatible(ctx context.Context, opts ...ProbeOption) *Query {
+	return q.with(func(r *Registry) Drivers { return r.FilterForCompatible(ctx, opts...) })
+}
+
+// Instantiate adds a step building the drivers added with RegisterFactory, see Registry.Instantiate.
+// Drivers that fail to build are dropped and their errors logged. The drivers are built with ctx,
+// and the Config of the bound registry, each time the query is run.
+func (q *Query) Instantiate(ctx context.Context) *Query {
+	return q.with(func(r *Registry) Drivers {
+		ready, err := r.Instantiate(ctx, r.active())
+		if err != nil {
+			r.Logger.Error(err, "unable to instantiate drivers, dropping them")
+		}
+		return ready
+	})
+}
+
+// Drivers runs the query against the current drivers of the bound registry.
+// An unbound query returns nil.
+func (q *Query) Drivers() Drivers {
+	if q.registry == nil {
+		return nil
+	}
+	return q.run(q.registry, q.registry.active())
+}
+
+// Apply runs the query against drivers. The bound registry, if any, is only used for its Logger and Config.
+func (q *Query) Apply(drivers Drivers) Drivers {
+	r := q.registry
+	if r == nil {
+		r = NewRegistry()
+	}
+	return q.run(r, drivers)
+}
+
+// GetDriverInterfaces runs the query and returns just the generic driver interfaces.
+func (q *Query) GetDriverInterfaces() []interface{} {
+	var results []interface{}
+	for _, elem := range q.Drivers() {
+		if elem != nil {
+			results = append(results, elem.DriverInterface)
+		}
+	}
+	return results
+}
+
+// with returns a copy of the query with step added.
+func (q *Query) with(step queryStep) *Query {
+	steps := make([]queryStep, len(q.steps), len(q.steps)+1)
+	copy(steps, q.steps)
+	return &Query{registry: q.registry, steps: append(steps, step)}
+}
+
+// run runs every step in order, each against a scratch registry holding the result of the previous one.
+// Compatibility results are recorded on r, so its subscribers see EventCompatibilityChanged.
+func (q *Query) run(r *Registry, drivers Drivers) Drivers {
+	for _, step := range q.steps {
+		drivers = step(&Registry{Logger: r.Logger, Config: r.Config, Drivers: drivers, owner: r})
+	}
+	return drivers
+}
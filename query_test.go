@@ -0,0 +1,78 @@
+package registrar
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestQuery(t *testing.T) {
+	rg := NewRegistry()
+	rg.Register("dell", "web", Features{FeaturePowerSet}, nil, &driverOne{isCompatible: true}, WithLabels(map[string]string{"vendor": "dell"}))
+	rg.Register("ipmitool", "ipmi", Features{FeaturePowerSet, FeatureUserCreate}, nil, &driverOne{isCompatible: true})
+	rg.Register("smc", "ipmi", Features{FeaturePowerSet}, nil, &driverOne{isCompatible: true}, WithLabels(map[string]string{"vendor": "supermicro"}))
+	rg.Register("broken", "ipmi", Features{FeaturePowerSet}, nil, &driverOne{isCompatible: false})
+	before := rg.Snapshot()
+
+	base := rg.Query().Supports(FeaturePowerSet)
+	ipmi := base.Using("ipmi").PreferDriver("smc").Compatible(context.Background())
+	web := base.Using("web")
+	testCases := map[string]struct {
+		query *Query
+		want  []string
+	}{
+		"empty query":   {query: rg.Query(), want: []string{"dell", "ipmitool", "smc", "broken"}},
+		"base":          {query: base, want: []string{"dell", "ipmitool", "smc", "broken"}},
+		"ipmi":          {query: ipmi, want: []string{"smc", "ipmitool"}},
+		"web":           {query: web, want: []string{"dell"}},
+		"for":           {query: base.For("ipmitool"), want: []string{"ipmitool"}},
+		"prefer proto":  {query: base.PreferProtocol("ipmi"), want: []string{"ipmitool", "smc", "broken", "dell"}},
+		"matching":      {query: base.Matching(Selector{{Key: "vendor", Operator: SelectorExists}}), want: []string{"dell", "smc"}},
+		"user create":   {query: ipmi.Supports(FeatureUserCreate), want: []string{"ipmitool"}},
+		"unbound query": {query: NewQuery().Using("ipmi")},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.query.Drivers().Names(), tc.want); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+
+	if diff := cmp.Diff(rg.Snapshot(), before, cmp.AllowUnexported(driverOne{})); diff != "" {
+		t.Fatalf("expected the registry to be unchanged: %v", diff)
+	}
+}
+
+func TestQueryReuse(t *testing.T) {
+	query := NewQuery().Using("ipmi").PreferDriver("smc")
+
+	host1 := NewRegistry()
+	host1.Register("ipmitool", "ipmi", nil, nil, nil)
+	host1.Register("smc", "ipmi", nil, nil, nil)
+	host2 := NewRegistry()
+	host2.Register("dell", "web", nil, nil, nil)
+	host2.Register("smc", "ipmi", nil, nil, nil)
+
+	if diff := cmp.Diff(query.On(host1).Drivers().Names(), []string{"smc", "ipmitool"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if diff := cmp.Diff(query.On(host2).Drivers().Names(), []string{"smc"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if diff := cmp.Diff(query.Apply(host1.Snapshot()).Names(), []string{"smc", "ipmitool"}); diff != "" {
+		t.Fatal(diff)
+	}
+
+	// drivers registered after the query was built are seen when it is run.
+	bound := query.On(host1)
+	host1.Register("newer", "ipmi", nil, nil, "impl")
+	if diff := cmp.Diff(bound.Drivers().Names(), []string{"smc", "ipmitool", "newer"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if diff := cmp.Diff(bound.GetDriverInterfaces(), []interface{}{nil, nil, "impl"}); diff != "" {
+		t.Fatal(diff)
+	}
+}
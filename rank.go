@@ -0,0 +1,108 @@
+package registrar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Scorer scores a driver for Rank. Higher scores rank first.
+type Scorer func(*Driver) float64
+
+// ByPriority scores a driver by its Priority.
+func ByPriority() Scorer {
+	return func(d *Driver) float64 { return float64(d.Priority) }
+}
+
+// ProtocolScores scores a driver by the score of its protocol. Protocols are matched
+// case insensitively and protocols not in scores score 0. For example, to strongly prefer
+// redfish, fall back to ipmi and use vendor web interfaces last:
+//
+//	ProtocolScores(map[string]float64{"redfish": 100, "ipmi": 10, "web": -10})
+func ProtocolScores(scores map[string]float64) Scorer {
+	return fieldScores(scores, func(d *Driver) string { return d.Protocol })
+}
+
+// DriverScores scores a driver by the score of its name. Names are matched
+// case insensitively and names not in scores score 0.
+func DriverScores(scores map[string]float64) Scorer {
+	return fieldScores(scores, func(d *Driver) string { return d.Name })
+}
+
+// FeatureCoverage scores a driver by the fraction, from 0 to 1, of features it supports.
+func FeatureCoverage(features ...Feature) Scorer {
+	return func(d *Driver) float64 {
+		if len(features) == 0 {
+			return 0
+		}
+		var supported int
+		for _, f := range features {
+			if d.Features.include(f) {
+				supported++
+			}
+		}
+		return float64(supported) / float64(len(features))
+	}
+}
+
+// Weighted multiplies the score of s by weight.
+func Weighted(weight float64, s Scorer) Scorer {
+	return func(d *Driver) float64 { return weight * s(d) }
+}
+
+// Rank returns the drivers ordered by the sum of their scores, highest first.
+// Drivers with equal scores keep their relative order. Nil drivers are dropped.
+func (d Drivers) Rank(scorers ...Scorer) Drivers {
+	ranked, _ := d.rank(scorers...)
+	return ranked
+}
+
+// Rank does the actual work of ordering drivers by the sum of their scores, highest first.
+// Drivers with equal scores keep their registration order.
+func (r *Registry) Rank(scorers ...Scorer) Drivers {
+	ranked, scores := r.active().rank(scorers...)
+	if r.Logger.V(1).Enabled() {
+		ids := driverIDs(ranked)
+		for i := range ids {
+			ids[i] = fmt.Sprintf("%s=%g", ids[i], scores[i])
+		}
+		r.logOrdered("Rank", ids, ranked)
+	}
+	return ranked
+}
+
+// rank orders the drivers by score and returns the score of each, in the new order.
+func (d Drivers) rank(scorers ...Scorer) (Drivers, []float64) {
+	type scored struct {
+		driver *Driver
+		score  float64
+	}
+	var all []scored
+	for _, reg := range d {
+		if reg == nil {
+			continue
+		}
+		var total float64
+		for _, s := range scorers {
+			total += s(reg)
+		}
+		all = append(all, scored{driver: reg, score: total})
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].score > all[j].score })
+	drivers := make(Drivers, 0, len(all))
+	scores := make([]float64, 0, len(all))
+	for _, elem := range all {
+		drivers = append(drivers, elem.driver)
+		scores = append(scores, elem.score)
+	}
+	return drivers, scores
+}
+
+// fieldScores scores a driver by looking up field in scores, case insensitively.
+func fieldScores(scores map[string]float64, field func(*Driver) string) Scorer {
+	lower := make(map[string]float64, len(scores))
+	for k, v := range scores {
+		lower[strings.ToLower(k)] = v
+	}
+	return func(d *Driver) float64 { return lower[strings.ToLower(field(d))] }
+}
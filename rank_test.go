@@ -0,0 +1,44 @@
+package registrar
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRank(t *testing.T) {
+	rg := NewRegistry()
+	rg.Register("dell", "web", Features{FeaturePowerSet}, nil, nil)
+	rg.Register("ipmitool", "ipmi", Features{FeaturePowerSet, FeatureUserCreate}, nil, nil)
+	rg.Register("gofish", "redfish", Features{FeaturePowerSet}, nil, nil, WithPriority(1))
+	rg.Register("smc", "web", Features{FeaturePowerSet, FeatureUserCreate}, nil, nil, WithPriority(5))
+	rg.Register("bmc-redfish", "Redfish", nil, nil, nil)
+
+	protocols := ProtocolScores(map[string]float64{"redfish": 100, "ipmi": 10, "web": -10})
+	testCases := map[string]struct {
+		scorers []Scorer
+		want    []string
+	}{
+		"no scorers keeps registration order": {want: []string{"dell", "ipmitool", "gofish", "smc", "bmc-redfish"}},
+		"priority":                            {scorers: []Scorer{ByPriority()}, want: []string{"smc", "gofish", "dell", "ipmitool", "bmc-redfish"}},
+		"protocol preference":                 {scorers: []Scorer{protocols}, want: []string{"gofish", "bmc-redfish", "ipmitool", "dell", "smc"}},
+		"feature coverage":                    {scorers: []Scorer{FeatureCoverage(FeaturePowerSet, FeatureUserCreate)}, want: []string{"ipmitool", "smc", "dell", "gofish", "bmc-redfish"}},
+		"combined": {
+			scorers: []Scorer{protocols, Weighted(3, ByPriority()), FeatureCoverage(FeatureUserCreate)},
+			want:    []string{"gofish", "bmc-redfish", "ipmitool", "smc", "dell"},
+		},
+		"driver scores":  {scorers: []Scorer{DriverScores(map[string]float64{"DELL": 1})}, want: []string{"dell", "ipmitool", "gofish", "smc", "bmc-redfish"}},
+		"caller defined": {scorers: []Scorer{func(d *Driver) float64 { return float64(len(d.Name)) }}, want: []string{"bmc-redfish", "ipmitool", "gofish", "dell", "smc"}},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(rg.Rank(tc.scorers...).Names(), tc.want); diff != "" {
+				t.Fatal(diff)
+			}
+			if diff := cmp.Diff(rg.Query().Rank(tc.scorers...).Drivers().Names(), tc.want); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
@@ -3,13 +3,46 @@ package registrar
 
 import (
 	"context"
-	"sort"
+	"errors"
+	"fmt"
+	"reflect"
 	"strings"
 	"sync"
 
 	"github.com/go-logr/logr"
 )
 
+var (
+	// ErrEmptyName is returned by RegisterE when the driver name is empty.
+	ErrEmptyName = errors.New("driver name is empty")
+	// ErrEmptyProtocol is returned by RegisterE when the driver protocol is empty.
+	ErrEmptyProtocol = errors.New("driver protocol is empty")
+	// ErrNilDriverInterface is returned by RegisterE when the driver implementation is nil.
+	ErrNilDriverInterface = errors.New("driver interface is nil")
+	// ErrDuplicateDriver is returned by RegisterE when a driver with the same name and protocol is already registered.
+	ErrDuplicateDriver = errors.New("driver with the same name and protocol already registered")
+	// ErrDuplicateFeature is returned by RegisterE when a feature is listed more than once.
+	ErrDuplicateFeature = errors.New("duplicate feature")
+)
+
+// RegistrationError is returned by RegisterE when a driver fails validation.
+// Err holds every problem found and can be inspected with errors.Is.
+type RegistrationError struct {
+	Name     string
+	Protocol string
+	Err      error
+}
+
+// Error implements the error interface.
+func (e *RegistrationError) Error() string {
+	return fmt.Sprintf("unable to register driver %q (protocol %q): %v", e.Name, e.Protocol, e.Err)
+}
+
+// Unwrap returns the underlying validation errors.
+func (e *RegistrationError) Unwrap() error {
+	return e.Err
+}
+
 // Feature represents a single feature a driver supports.
 type Feature string
 
@@ -22,6 +55,9 @@ type Drivers []*Driver
 // Option for setting optional Registry values.
 type Option func(*Registry)
 
+// DriverOption for setting optional Driver values at registration.
+type DriverOption func(*Driver)
+
 // Verifier allows implementations to define a method for
 // determining whether a driver is compatible for use.
 type Verifier interface {
@@ -29,9 +65,31 @@ type Verifier interface {
 }
 
 // Registry holds the registered drivers.
+// All Registry methods are safe for concurrent use. Reading or assigning the
+// Drivers field directly is not synchronized; use Snapshot and SetDrivers when
+// the Registry is shared between goroutines. Registry queries skip disabled drivers
+// and include the drivers inherited from a parent registry, see WithParent.
 type Registry struct {
 	Logger  logr.Logger
 	Drivers Drivers
+	// Config is passed to the Factory of every driver built by Instantiate.
+	Config Config
+
+	mu sync.RWMutex
+	// opened holds the drivers opened by Open, in the order they were opened.
+	opened Drivers
+	// opening holds the drivers an Open call is opening, see Open.
+	opening map[interface{}]*openCall
+	// subscribers receive every change to the drivers, see Subscribe. Subscribers of descendant
+	// registries skip the events of drivers their hiddenFunc reports as overridden.
+	subscribers map[*subscriber]hiddenFunc
+	// compatible holds the last known compatibility of each driver, by name and protocol.
+	compatible map[driverKey]bool
+	// owner, when set, is the registry compatibility results are recorded on instead,
+	// for the scratch registries a Query runs its steps against.
+	owner *Registry
+	// parent, when set, provides the drivers this registry inherits, see WithParent.
+	parent *Registry
 }
 
 // Driver holds the info about a driver.
@@ -41,6 +99,14 @@ type Driver struct {
 	Features        Features
 	Metadata        interface{}
 	DriverInterface interface{}
+	// Labels are queryable key value pairs describing the driver, see Select.
+	Labels map[string]string
+	// Priority ranks the driver against others, higher first, see Rank and ByPriority.
+	Priority int
+	// Factory builds DriverInterface on demand for drivers added with RegisterFactory, see Instantiate.
+	Factory Factory
+	// Disabled drivers are skipped by the Registry queries, see Disable.
+	Disabled bool
 }
 
 // WithLogger sets the logger.
@@ -53,6 +119,21 @@ func WithDrivers(drivers Drivers) Option {
 	return func(args *Registry) { args.Drivers = drivers }
 }
 
+// WithConfig sets the config passed to driver factories.
+func WithConfig(config Config) Option {
+	return func(args *Registry) { args.Config = config }
+}
+
+// WithLabels sets the labels of a driver.
+func WithLabels(labels map[string]string) DriverOption {
+	return func(args *Driver) { args.Labels = labels }
+}
+
+// WithPriority sets the priority of a driver.
+func WithPriority(priority int) DriverOption {
+	return func(args *Driver) { args.Priority = priority }
+}
+
 // NewRegistry returns a new Driver registry.
 func NewRegistry(opts ...Option) *Registry {
 	defaultRegistry := &Registry{
@@ -66,75 +147,149 @@ func NewRegistry(opts ...Option) *Registry {
 }
 
 // Register will add a driver a Driver registry.
-func (r *Registry) Register(name, protocol string, features Features, metadata interface{}, driverInterface interface{}) {
-	r.Drivers = append(r.Drivers, &Driver{
+func (r *Registry) Register(name, protocol string, features Features, metadata interface{}, driverInterface interface{}, opts ...DriverOption) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := newDriver(name, protocol, features, metadata, driverInterface, opts...)
+	r.Drivers = append(r.Drivers, d)
+	r.emit(EventRegistered, d)
+	r.Logger.V(1).Info("driver registered", "name", name, "protocol", protocol, "features", features)
+}
+
+// RegisterE will add a driver to a Driver registry after validating it.
+// Unlike Register, empty names or protocols, a nil driverInterface, duplicate features
+// and a name and protocol pair that is already registered are rejected with a *RegistrationError.
+func (r *Registry) RegisterE(name, protocol string, features Features, metadata interface{}, driverInterface interface{}, opts ...DriverOption) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var errs []error
+	if name == "" {
+		errs = append(errs, ErrEmptyName)
+	}
+	if protocol == "" {
+		errs = append(errs, ErrEmptyProtocol)
+	}
+	if isNil(driverInterface) {
+		errs = append(errs, ErrNilDriverInterface)
+	}
+	seen := make(map[Feature]struct{})
+	for _, f := range features {
+		if _, ok := seen[f]; ok {
+			errs = append(errs, fmt.Errorf("%w: %q", ErrDuplicateFeature, f))
+		}
+		seen[f] = struct{}{}
+	}
+	for _, elem := range r.Drivers {
+		if elem != nil && strings.EqualFold(elem.Name, name) && strings.EqualFold(elem.Protocol, protocol) {
+			errs = append(errs, ErrDuplicateDriver)
+			break
+		}
+	}
+	if len(errs) > 0 {
+		err := &RegistrationError{Name: name, Protocol: protocol, Err: errors.Join(errs...)}
+		r.Logger.V(1).Info("driver registration rejected", "name", name, "protocol", protocol, "error", err.Err.Error())
+		return err
+	}
+	d := newDriver(name, protocol, features, metadata, driverInterface, opts...)
+	r.Drivers = append(r.Drivers, d)
+	r.emit(EventRegistered, d)
+	r.Logger.V(1).Info("driver registered", "name", name, "protocol", protocol, "features", features)
+
+	return nil
+}
+
+// newDriver returns a Driver with opts applied.
+func newDriver(name, protocol string, features Features, metadata interface{}, driverInterface interface{}, opts ...DriverOption) *Driver {
+	d := &Driver{
 		Name:            name,
 		Protocol:        protocol,
 		Features:        features,
 		Metadata:        metadata,
 		DriverInterface: driverInterface,
-	})
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
 }
 
-// GetDriverInterfaces returns a slice of just the generic driver interfaces.
-func (r Registry) GetDriverInterfaces() []interface{} {
-	var results []interface{}
-	for _, elem := range r.Drivers {
-		if elem != nil {
-			results = append(results, elem.DriverInterface)
-		}
+// isNil reports whether i is nil or holds a nil pointer, map, slice, func, channel or interface.
+func isNil(i interface{}) bool {
+	if i == nil {
+		return true
+	}
+	v := reflect.ValueOf(i)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		return v.IsNil()
+	default:
+		return false
 	}
-	return results
 }
 
-// FilterForCompatible updates the driver registry with only compatible implementations.
-// compatible implementations are determined by running the Compatible method of the Verifier
-// interface. registered drivers must implement the Verifier interface for this. Order is preserved.
-func (r Registry) FilterForCompatible(ctx context.Context) Drivers {
-	var wg sync.WaitGroup
-	mutex := &sync.Mutex{}
-	order := make(map[int]*Driver)
-
-	for idx, elem := range r.Drivers {
-		if elem == nil {
-			continue
-		}
-		wg.Add(1)
-		go func(isCompat interface{}, reg *Driver, wg *sync.WaitGroup, num int) {
-			switch c := isCompat.(type) {
-			case Verifier:
-				if c.Compatible(ctx) {
-					mutex.Lock()
-					order[num] = reg
-					mutex.Unlock()
-				}
-			default:
-				mutex.Lock()
-				order[num] = reg
-				mutex.Unlock()
-			}
-			wg.Done()
-		}(elem.DriverInterface, elem, &wg, idx)
+// Snapshot returns a copy of the registered drivers.
+// The copy is not affected by later registrations. Drivers inherited from a parent are not included.
+func (r *Registry) Snapshot() Drivers {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.Drivers == nil {
+		return nil
 	}
-	wg.Wait()
-
-	return toSlice(order)
+	snapshot := make(Drivers, len(r.Drivers))
+	copy(snapshot, r.Drivers)
+	return snapshot
 }
 
-// toSlice converts a map to an order slice of Drivers.
-func toSlice(d map[int]*Driver) []*Driver {
-	keys := make([]int, 0, len(d))
-	for k := range d {
-		keys = append(keys, k)
+// active returns a copy of the registered drivers that are not disabled, including those
+// inherited from the parent, see Layered. Registry queries start from it.
+func (r *Registry) active() Drivers {
+	if r.parent == nil {
+		return r.Snapshot().Enabled()
+	}
+	layered := r.Layered()
+	drivers := make(Drivers, 0, len(layered))
+	for _, elem := range layered {
+		drivers = append(drivers, elem.Driver)
 	}
-	sort.Ints(keys)
+	return drivers.Enabled()
+}
 
-	var result []*Driver
-	for _, key := range keys {
-		result = append(result, d[key])
+// SetDrivers replaces the registered drivers.
+// It is the synchronized equivalent of assigning to the Drivers field, and also
+// emits EventUnregistered for every removed driver and EventRegistered for every added one.
+func (r *Registry) SetDrivers(drivers Drivers) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old := make(map[*Driver]struct{}, len(r.Drivers))
+	for _, elem := range r.Drivers {
+		old[elem] = struct{}{}
 	}
+	updated := make(map[*Driver]struct{}, len(drivers))
+	for _, elem := range drivers {
+		updated[elem] = struct{}{}
+	}
+	for _, elem := range r.Drivers {
+		if _, ok := updated[elem]; !ok && elem != nil {
+			r.emit(EventUnregistered, elem)
+		}
+	}
+	for _, elem := range drivers {
+		if _, ok := old[elem]; !ok && elem != nil {
+			r.emit(EventRegistered, elem)
+		}
+	}
+	r.Drivers = drivers
+}
 
-	return result
+// GetDriverInterfaces returns a slice of just the generic driver interfaces. Disabled drivers are skipped.
+func (r *Registry) GetDriverInterfaces() []interface{} {
+	var results []interface{}
+	for _, elem := range r.active() {
+		if elem != nil {
+			results = append(results, elem.DriverInterface)
+		}
+	}
+	return results
 }
 
 // include does the actual work of filtering for specific features.
@@ -155,35 +310,26 @@ func (f Features) include(features ...Feature) bool {
 }
 
 // Supports does the actual work of filtering for specific features.
-func (r Registry) Supports(features ...Feature) Drivers {
-	var supportedRegistries Drivers
-	for _, reg := range r.Drivers {
-		if reg.Features.include(features...) {
-			supportedRegistries = append(supportedRegistries, reg)
-		}
-	}
+func (r *Registry) Supports(features ...Feature) Drivers {
+	drivers := r.active()
+	supportedRegistries := drivers.Supports(features...)
+	r.logFiltered("Supports", features, drivers, supportedRegistries)
 	return supportedRegistries
 }
 
 // Using does the actual work of filtering for a specific protocol type.
-func (r Registry) Using(proto string) Drivers {
-	var supportedRegistries Drivers
-	for _, reg := range r.Drivers {
-		if reg.Protocol == proto {
-			supportedRegistries = append(supportedRegistries, reg)
-		}
-	}
+func (r *Registry) Using(proto string) Drivers {
+	drivers := r.active()
+	supportedRegistries := drivers.Using(proto)
+	r.logFiltered("Using", proto, drivers, supportedRegistries)
 	return supportedRegistries
 }
 
 // For does the actual work of filtering for a specific driver name.
-func (r Registry) For(driver string) Drivers {
-	var supportedRegistries Drivers
-	for _, reg := range r.Drivers {
-		if reg.Name == driver {
-			supportedRegistries = append(supportedRegistries, reg)
-		}
-	}
+func (r *Registry) For(driver string) Drivers {
+	drivers := r.active()
+	supportedRegistries := drivers.For(driver)
+	r.logFiltered("For", driver, drivers, supportedRegistries)
 	return supportedRegistries
 }
 
@@ -205,51 +351,15 @@ func deduplicate(s []string) []string {
 }
 
 // PreferProtocol does the actual work of moving preferred protocols to the start of the driver registry.
-func (r Registry) PreferProtocol(protocols ...string) Drivers {
-	var final Drivers
-	var leftOver Drivers
-	tracking := make(map[int]Drivers)
-	protocols = deduplicate(protocols)
-	for _, registry := range r.Drivers {
-		var movedToTracking bool
-		for index, pName := range protocols {
-			if strings.EqualFold(registry.Protocol, pName) {
-				tracking[index] = append(tracking[index], registry)
-				movedToTracking = true
-			}
-		}
-		if !movedToTracking {
-			leftOver = append(leftOver, registry)
-		}
-	}
-	for x := 0; x <= len(tracking); x++ {
-		final = append(final, tracking[x]...)
-	}
-	final = append(final, leftOver...)
+func (r *Registry) PreferProtocol(protocols ...string) Drivers {
+	final := r.active().PreferProtocol(protocols...)
+	r.logOrdered("PreferProtocol", deduplicate(protocols), final)
 	return final
 }
 
 // PreferDriver will reorder the registry by moving preferred drivers to the start.
-func (r Registry) PreferDriver(drivers ...string) Drivers {
-	var final Drivers
-	var leftOver Drivers
-	tracking := make(map[int]Drivers)
-	drivers = deduplicate(drivers)
-	for _, registry := range r.Drivers {
-		var movedToTracking bool
-		for index, pName := range drivers {
-			if strings.EqualFold(registry.Name, pName) {
-				tracking[index] = append(tracking[index], registry)
-				movedToTracking = true
-			}
-		}
-		if !movedToTracking {
-			leftOver = append(leftOver, registry)
-		}
-	}
-	for x := 0; x <= len(tracking); x++ {
-		final = append(final, tracking[x]...)
-	}
-	final = append(final, leftOver...)
+func (r *Registry) PreferDriver(drivers ...string) Drivers {
+	final := r.active().PreferDriver(drivers...)
+	r.logOrdered("PreferDriver", deduplicate(drivers), final)
 	return final
 }
@@ -2,6 +2,9 @@ package registrar
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/go-logr/logr"
@@ -220,7 +223,7 @@ func TestAll(t *testing.T) {
 			if tc.addARegistry {
 				rg.Register("dell", "web", []Feature{FeaturePowerSet}, nil, nil)
 			}
-			if diff := cmp.Diff(rg, tc.want, cmpopts.IgnoreFields(Registry{}, "Logger")); diff != "" {
+			if diff := cmp.Diff(rg, tc.want, cmpopts.IgnoreFields(Registry{}, "Logger"), cmpopts.IgnoreUnexported(Registry{})); diff != "" {
 				t.Fatal(diff)
 			}
 		})
@@ -543,3 +546,100 @@ func TestFilterForCompatibleNotAVerifier(t *testing.T) {
 		})
 	}
 }
+
+func TestConcurrentRegistry(t *testing.T) {
+	rg := NewRegistry()
+	const workers = 10
+	const perWorker = 50
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				d := &driverOne{name: fmt.Sprintf("driver-%d-%d", w, i), protocol: "tcp", features: Features{FeaturePowerSet}, isCompatible: i%2 == 0}
+				rg.Register(d.name, d.protocol, d.features, nil, d)
+			}
+		}(w)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				rg.Supports(FeaturePowerSet)
+				rg.Using("tcp")
+				rg.PreferDriver("driver-0-0")
+				rg.FilterForCompatible(context.Background())
+				rg.GetDriverInterfaces()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := len(rg.Snapshot()); got != workers*perWorker {
+		t.Fatalf("expected %d drivers, got %d", workers*perWorker, got)
+	}
+	if got := len(rg.FilterForCompatible(context.Background())); got != workers*perWorker/2 {
+		t.Fatalf("expected %d compatible drivers, got %d", workers*perWorker/2, got)
+	}
+}
+
+func TestSetDrivers(t *testing.T) {
+	rg := NewRegistry()
+	rg.Register("dell", "web", nil, nil, nil)
+	snapshot := rg.Snapshot()
+	rg.SetDrivers(rg.Using("ipmi"))
+	if len(rg.Snapshot()) != 0 {
+		t.Fatalf("expected no drivers, got %v", rg.Snapshot())
+	}
+	if len(snapshot) != 1 {
+		t.Fatalf("expected snapshot to be unaffected, got %v", snapshot)
+	}
+}
+
+func TestRegisterE(t *testing.T) {
+	var nilDriver *driverOne
+	testCases := map[string]struct {
+		name      string
+		protocol  string
+		features  Features
+		impl      interface{}
+		wantErrs  []error
+		wantCount int
+	}{
+		"valid":                {name: "smc", protocol: "web", features: Features{FeaturePowerSet}, impl: &driverOne{}, wantCount: 2},
+		"duplicate driver":     {name: "dell", protocol: "web", impl: &driverOne{}, wantErrs: []error{ErrDuplicateDriver}, wantCount: 1},
+		"duplicate any case":   {name: "DELL", protocol: "Web", impl: &driverOne{}, wantErrs: []error{ErrDuplicateDriver}, wantCount: 1},
+		"same name other":      {name: "dell", protocol: "redfish", impl: &driverOne{}, wantCount: 2},
+		"empty identifiers":    {impl: &driverOne{}, wantErrs: []error{ErrEmptyName, ErrEmptyProtocol}, wantCount: 1},
+		"nil implementation":   {name: "smc", protocol: "web", wantErrs: []error{ErrNilDriverInterface}, wantCount: 1},
+		"typed nil":            {name: "smc", protocol: "web", impl: nilDriver, wantErrs: []error{ErrNilDriverInterface}, wantCount: 1},
+		"duplicate features":   {name: "smc", protocol: "web", features: Features{FeaturePowerSet, FeaturePowerSet}, impl: &driverOne{}, wantErrs: []error{ErrDuplicateFeature}, wantCount: 1},
+		"multiple validations": {name: "dell", protocol: "web", features: Features{FeaturePowerSet, FeaturePowerSet}, wantErrs: []error{ErrDuplicateDriver, ErrDuplicateFeature, ErrNilDriverInterface}, wantCount: 1},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			rg := NewRegistry()
+			if err := rg.RegisterE("dell", "web", nil, nil, &driverOne{}); err != nil {
+				t.Fatal(err)
+			}
+			err := rg.RegisterE(tc.name, tc.protocol, tc.features, nil, tc.impl)
+			if len(tc.wantErrs) == 0 && err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if len(tc.wantErrs) > 0 {
+				var regErr *RegistrationError
+				if !errors.As(err, &regErr) {
+					t.Fatalf("expected a *RegistrationError, got %v", err)
+				}
+			}
+			for _, want := range tc.wantErrs {
+				if !errors.Is(err, want) {
+					t.Errorf("expected error to wrap %v, got %v", want, err)
+				}
+			}
+			if got := len(rg.Snapshot()); got != tc.wantCount {
+				t.Fatalf("expected %d drivers, got %d", tc.wantCount, got)
+			}
+		})
+	}
+}
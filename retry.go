@@ -0,0 +1,126 @@
+package registrar
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// RetryPolicy decides how many times, and how often, a driver call is retried
+// before the executors fall through to the next driver.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first. Values below 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts. 0 means no cap.
+	MaxBackoff time.Duration
+	// Multiplier grows the wait after every attempt. Values below 1 are treated as 2.
+	Multiplier float64
+	// Jitter randomizes each wait by up to this fraction of it, from 0 to 1.
+	Jitter float64
+	// Retryable reports whether an error is worth retrying. When nil every error is retried.
+	// Context errors and ErrCircuitOpen are never retried.
+	Retryable func(error) bool
+}
+
+// retryPolicies holds the retry policies of an execution, from least to most specific.
+type retryPolicies struct {
+	global    *RetryPolicy
+	protocols map[string]RetryPolicy
+	drivers   map[driverKey]RetryPolicy
+}
+
+// WithRetryPolicy sets the retry policy for every driver.
+func WithRetryPolicy(policy RetryPolicy) ExecOption {
+	return func(args *execConfig) { args.retry.global = &policy }
+}
+
+// WithProtocolRetryPolicy sets the retry policy for drivers of a protocol, matched case insensitively.
+// It takes precedence over WithRetryPolicy.
+func WithProtocolRetryPolicy(protocol string, policy RetryPolicy) ExecOption {
+	return func(args *execConfig) {
+		if args.retry.protocols == nil {
+			args.retry.protocols = make(map[string]RetryPolicy)
+		}
+		args.retry.protocols[strings.ToLower(protocol)] = policy
+	}
+}
+
+// WithDriverRetryPolicy sets the retry policy for the driver with a name and protocol, matched case insensitively.
+// It takes precedence over WithProtocolRetryPolicy and WithRetryPolicy.
+func WithDriverRetryPolicy(name, protocol string, policy RetryPolicy) ExecOption {
+	return func(args *execConfig) {
+		if args.retry.drivers == nil {
+			args.retry.drivers = make(map[driverKey]RetryPolicy)
+		}
+		args.retry.drivers[driverKey{name: strings.ToLower(name), protocol: strings.ToLower(protocol)}] = policy
+	}
+}
+
+// forDriver returns the most specific retry policy for a driver. The zero RetryPolicy does not retry.
+func (r retryPolicies) forDriver(reg *Driver) RetryPolicy {
+	if policy, ok := r.drivers[driverKey{name: strings.ToLower(reg.Name), protocol: strings.ToLower(reg.Protocol)}]; ok {
+		return policy
+	}
+	if policy, ok := r.protocols[strings.ToLower(reg.Protocol)]; ok {
+		return policy
+	}
+	if r.global != nil {
+		return *r.global
+	}
+	return RetryPolicy{}
+}
+
+// retryable reports whether another attempt should be made after err.
+func (p RetryPolicy) retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// Backoff returns the wait before the given retry, starting at 1 for the first retry.
+// Without a MaxBackoff the wait grows until it is capped at the largest time.Duration.
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	if p.InitialBackoff <= 0 {
+		return 0
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	// float64(math.MaxInt64) rounds up to 2^63, which does not fit in a time.Duration,
+	// so waits are kept below it before and after jitter is applied.
+	maxWait := float64(math.MaxInt64)
+	if p.MaxBackoff > 0 {
+		maxWait = float64(p.MaxBackoff)
+	}
+	wait := math.Min(float64(p.InitialBackoff)*math.Pow(multiplier, float64(retry-1)), maxWait)
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		wait += wait * jitter * (2*rand.Float64() - 1)
+	}
+	if wait >= float64(math.MaxInt64) {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(wait)
+}
+
+// sleep waits for d or until ctx is done, returning the context error in the latter case.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
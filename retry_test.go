@@ -0,0 +1,173 @@
+package registrar
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var errPermanent = errors.New("permanent failure")
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+	var got []time.Duration
+	for retry := 1; retry <= 4; retry++ {
+		got = append(got, policy.Backoff(retry))
+	}
+	want := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 50 * time.Millisecond}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Fatal(diff)
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if b := policy.Backoff(2); b < 10*time.Millisecond || b > 30*time.Millisecond {
+			t.Fatalf("expected jittered backoff within 50%% of 20ms, got %v", b)
+		}
+	}
+}
+
+func TestRetryPolicyBackoffOverflow(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Second, Jitter: 0.5}
+	for _, retry := range []int{64, 100, 2000} {
+		if b := policy.Backoff(retry); b <= 0 {
+			t.Fatalf("expected an uncapped backoff to saturate, got %v for retry %d", b, retry)
+		}
+	}
+	if b := (RetryPolicy{}).Backoff(2000); b != 0 {
+		t.Fatalf("expected no backoff without an initial backoff, got %v", b)
+	}
+}
+
+func TestFirstSuccessRetries(t *testing.T) {
+	drivers := Drivers{{Name: "redfish", Protocol: "redfish"}, {Name: "ipmitool", Protocol: "ipmi"}, {Name: "webui", Protocol: "web"}}
+	testCases := map[string]struct {
+		opts         []ExecOption
+		failures     map[string]int
+		permanent    map[string]bool
+		wantWinner   string
+		wantCalls    map[string]int
+		wantAttempts map[string]int
+	}{
+		"no policy does not retry": {
+			failures:     map[string]int{"redfish": 1},
+			wantWinner:   "ipmitool",
+			wantCalls:    map[string]int{"redfish": 1, "ipmitool": 1},
+			wantAttempts: map[string]int{"redfish": 1},
+		},
+		"global policy retries before falling through": {
+			opts:       []ExecOption{WithRetryPolicy(RetryPolicy{MaxAttempts: 3})},
+			failures:   map[string]int{"redfish": 2},
+			wantWinner: "redfish",
+			wantCalls:  map[string]int{"redfish": 3},
+		},
+		"attempts are exhausted": {
+			opts:         []ExecOption{WithRetryPolicy(RetryPolicy{MaxAttempts: 2})},
+			failures:     map[string]int{"redfish": 5, "ipmitool": 5},
+			wantWinner:   "webui",
+			wantCalls:    map[string]int{"redfish": 2, "ipmitool": 2, "webui": 1},
+			wantAttempts: map[string]int{"redfish": 2, "ipmitool": 2},
+		},
+		"protocol and driver policies take precedence": {
+			opts: []ExecOption{
+				WithRetryPolicy(RetryPolicy{MaxAttempts: 5}),
+				WithProtocolRetryPolicy("IPMI", RetryPolicy{MaxAttempts: 2}),
+				WithDriverRetryPolicy("redfish", "redfish", RetryPolicy{}),
+			},
+			failures:     map[string]int{"redfish": 5, "ipmitool": 5},
+			wantWinner:   "webui",
+			wantCalls:    map[string]int{"redfish": 1, "ipmitool": 2, "webui": 1},
+			wantAttempts: map[string]int{"redfish": 1, "ipmitool": 2},
+		},
+		"driver policy matched case insensitively": {
+			opts: []ExecOption{
+				WithRetryPolicy(RetryPolicy{MaxAttempts: 5}),
+				WithDriverRetryPolicy("RedFish", "REDFISH", RetryPolicy{MaxAttempts: 2}),
+			},
+			failures:     map[string]int{"redfish": 5},
+			wantWinner:   "ipmitool",
+			wantCalls:    map[string]int{"redfish": 2, "ipmitool": 1},
+			wantAttempts: map[string]int{"redfish": 2},
+		},
+		"errors that are not retryable": {
+			opts:         []ExecOption{WithRetryPolicy(RetryPolicy{MaxAttempts: 3, Retryable: func(err error) bool { return !errors.Is(err, errPermanent) }})},
+			failures:     map[string]int{"redfish": 5},
+			permanent:    map[string]bool{"redfish": true},
+			wantWinner:   "ipmitool",
+			wantCalls:    map[string]int{"redfish": 1, "ipmitool": 1},
+			wantAttempts: map[string]int{"redfish": 1},
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			calls := map[string]int{}
+			fn := func(_ context.Context, d *Driver) error {
+				calls[d.Name]++
+				if calls[d.Name] <= tc.failures[d.Name] {
+					if tc.permanent[d.Name] {
+						return errPermanent
+					}
+					return errDriverFailed
+				}
+				return nil
+			}
+			winner, err := FirstSuccess(context.Background(), drivers, fn, tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if winner.Name != tc.wantWinner {
+				t.Fatalf("expected %v to win, got %v", tc.wantWinner, winner.Name)
+			}
+			if diff := cmp.Diff(calls, tc.wantCalls); diff != "" {
+				t.Fatal(diff)
+			}
+
+			// run again with every driver failing to inspect the attempt counts.
+			calls = map[string]int{}
+			tc.failures = map[string]int{"redfish": 10, "ipmitool": 10, "webui": 10}
+			_, err = FirstSuccess(context.Background(), drivers[:2], fn, tc.opts...)
+			var derrs DriverErrors
+			if !errors.As(err, &derrs) {
+				t.Fatalf("expected DriverErrors, got %v", err)
+			}
+			for _, derr := range derrs {
+				if want, ok := tc.wantAttempts[derr.Name]; ok && derr.Attempts != want {
+					t.Fatalf("expected %d attempts for %v, got %d", want, derr.Name, derr.Attempts)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryStopsWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var calls int
+	results := All(ctx, Drivers{{Name: "dell", Protocol: "web"}}, func(context.Context, *Driver) (bool, error) {
+		calls++
+		return false, errDriverFailed
+	}, WithRetryPolicy(RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Minute}))
+	if calls != 1 || results[0].Attempts != 1 {
+		t.Fatalf("expected a single attempt before the context was done, got %d calls and %d attempts", calls, results[0].Attempts)
+	}
+}
+
+func TestRetryRespectsBreakers(t *testing.T) {
+	b := NewBreakers(WithFailureThreshold(2))
+	var calls int
+	_, err := FirstSuccess(context.Background(), Drivers{{Name: "dell", Protocol: "web"}}, func(context.Context, *Driver) error {
+		calls++
+		return errDriverFailed
+	}, WithRetryPolicy(RetryPolicy{MaxAttempts: 5}), WithBreakers(b))
+	if calls != 2 {
+		t.Fatalf("expected retries to stop once the circuit opened, got %d calls", calls)
+	}
+	var derrs DriverErrors
+	if !errors.As(err, &derrs) || derrs[0].Attempts != 2 || !errors.Is(err, ErrCircuitOpen) || !errors.Is(err, errDriverFailed) {
+		t.Fatalf("expected 2 attempts ending with an open circuit and the last driver error, got %v", err)
+	}
+}
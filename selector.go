@@ -0,0 +1,288 @@
+package registrar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SelectorOperator is the comparison a selector Requirement makes against a label.
+type SelectorOperator string
+
+const (
+	// SelectorEquals matches when the label has the single given value.
+	SelectorEquals SelectorOperator = "="
+	// SelectorNotEquals matches when the label is missing or does not have the given value.
+	SelectorNotEquals SelectorOperator = "!="
+	// SelectorIn matches when the label has one of the given values.
+	SelectorIn SelectorOperator = "in"
+	// SelectorNotIn matches when the label is missing or has none of the given values.
+	SelectorNotIn SelectorOperator = "notin"
+	// SelectorExists matches when the label is present.
+	SelectorExists SelectorOperator = "exists"
+	// SelectorDoesNotExist matches when the label is missing.
+	SelectorDoesNotExist SelectorOperator = "!"
+)
+
+// Requirement is a single condition on a driver label.
+type Requirement struct {
+	Key      string
+	Operator SelectorOperator
+	Values   []string
+}
+
+// Selector is a set of Requirements that must all match, see ParseSelector.
+// An empty Selector matches every driver.
+type Selector []Requirement
+
+// SelectorError is returned by ParseSelector for a selector that can not be parsed.
+type SelectorError struct {
+	Selector string
+	// Pos is the byte offset in Selector where the problem was found.
+	Pos int
+	Msg string
+}
+
+// Error implements the error interface.
+func (e *SelectorError) Error() string {
+	return fmt.Sprintf("invalid selector %q at position %d: %s", e.Selector, e.Pos, e.Msg)
+}
+
+// Matches reports whether the requirement is satisfied by labels.
+func (req Requirement) Matches(labels map[string]string) bool {
+	val, ok := labels[req.Key]
+	switch req.Operator {
+	case SelectorEquals, SelectorIn:
+		return ok && contains(req.Values, val)
+	case SelectorNotEquals, SelectorNotIn:
+		return !ok || !contains(req.Values, val)
+	case SelectorExists:
+		return ok
+	case SelectorDoesNotExist:
+		return !ok
+	default:
+		return false
+	}
+}
+
+// String returns the requirement in selector syntax.
+func (req Requirement) String() string {
+	switch req.Operator {
+	case SelectorExists:
+		return req.Key
+	case SelectorDoesNotExist:
+		return "!" + req.Key
+	case SelectorIn, SelectorNotIn:
+		return fmt.Sprintf("%s %s (%s)", req.Key, req.Operator, strings.Join(req.Values, ","))
+	case SelectorEquals, SelectorNotEquals:
+		return fmt.Sprintf("%s%s%s", req.Key, req.Operator, strings.Join(req.Values, ""))
+	default:
+		return ""
+	}
+}
+
+// Matches reports whether every requirement is satisfied by labels.
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, req := range s {
+		if !req.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the selector in selector syntax.
+func (s Selector) String() string {
+	reqs := make([]string, 0, len(s))
+	for _, req := range s {
+		reqs = append(reqs, req.String())
+	}
+	return strings.Join(reqs, ",")
+}
+
+// Select returns the drivers whose Labels match the selector, see ParseSelector for the syntax.
+func (r *Registry) Select(selector string) (Drivers, error) {
+	sel, err := ParseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	return r.Matching(sel), nil
+}
+
+// Matching returns the drivers whose Labels match an already parsed selector.
+func (r *Registry) Matching(sel Selector) Drivers {
+	drivers := r.active()
+	supportedRegistries := drivers.Matching(sel)
+	r.logFiltered("Matching", sel.String(), drivers, supportedRegistries)
+	return supportedRegistries
+}
+
+// ParseSelector parses a Kubernetes style label selector.
+// A selector is a comma separated list of requirements, all of which must match:
+//
+//	vendor=dell          label equals value (== is also accepted)
+//	vendor!=dell         label is missing or does not equal value
+//	model in (r640,r740) label is one of the values
+//	model notin (r640)   label is missing or none of the values
+//	deprecated           label is present
+//	!deprecated          label is missing
+func ParseSelector(selector string) (Selector, error) {
+	p := &selectorParser{input: selector}
+	var sel Selector
+	p.skipSpace()
+	if p.pos == len(p.input) {
+		return sel, nil
+	}
+	for {
+		req, err := p.requirement()
+		if err != nil {
+			return nil, err
+		}
+		sel = append(sel, req)
+		p.skipSpace()
+		if p.pos == len(p.input) {
+			return sel, nil
+		}
+		if p.input[p.pos] != ',' {
+			return nil, p.errorf("expected ',' but found %q", p.input[p.pos])
+		}
+		p.pos++
+	}
+}
+
+// selectorParser holds the state of parsing a selector.
+type selectorParser struct {
+	input string
+	pos   int
+}
+
+// requirement parses a single selector requirement.
+func (p *selectorParser) requirement() (Requirement, error) {
+	p.skipSpace()
+	if p.consume("!") {
+		key, err := p.word("label key")
+		if err != nil {
+			return Requirement{}, err
+		}
+		return Requirement{Key: key, Operator: SelectorDoesNotExist}, nil
+	}
+	key, err := p.word("label key")
+	if err != nil {
+		return Requirement{}, err
+	}
+	p.skipSpace()
+	switch {
+	case p.consume("!="):
+		return p.single(key, SelectorNotEquals), nil
+	case p.consume("=="), p.consume("="):
+		return p.single(key, SelectorEquals), nil
+	case p.pos == len(p.input) || p.input[p.pos] == ',':
+		return Requirement{Key: key, Operator: SelectorExists}, nil
+	}
+	op, err := p.word("operator")
+	if err != nil {
+		return Requirement{}, err
+	}
+	switch SelectorOperator(op) {
+	case SelectorIn, SelectorNotIn:
+		values, err := p.set()
+		if err != nil {
+			return Requirement{}, err
+		}
+		return Requirement{Key: key, Operator: SelectorOperator(op), Values: values}, nil
+	default:
+		p.pos -= len(op)
+		return Requirement{}, p.errorf("unknown operator %q, expected one of =, ==, !=, in, notin", op)
+	}
+}
+
+// single parses the value of an equality requirement. An empty value is allowed.
+func (p *selectorParser) single(key string, op SelectorOperator) Requirement {
+	p.skipSpace()
+	return Requirement{Key: key, Operator: op, Values: []string{p.scan()}}
+}
+
+// set parses a parenthesized, comma separated list of values.
+func (p *selectorParser) set() ([]string, error) {
+	p.skipSpace()
+	if !p.consume("(") {
+		return nil, p.errorf("expected '(' to start a set of values")
+	}
+	var values []string
+	for {
+		p.skipSpace()
+		value, err := p.word("value")
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		p.skipSpace()
+		if p.consume(")") {
+			sort.Strings(values)
+			return values, nil
+		}
+		if !p.consume(",") {
+			if p.pos == len(p.input) {
+				return nil, p.errorf("expected ')' to close the set of values")
+			}
+			return nil, p.errorf("expected ',' or ')' but found %q", p.input[p.pos])
+		}
+	}
+}
+
+// word parses a non empty key, value or operator. what describes it in errors.
+func (p *selectorParser) word(what string) (string, error) {
+	w := p.scan()
+	if w == "" {
+		if p.pos == len(p.input) {
+			return "", p.errorf("expected %s but reached the end", what)
+		}
+		return "", p.errorf("expected %s but found %q", what, p.input[p.pos])
+	}
+	return w, nil
+}
+
+// scan returns the, possibly empty, run of key and value characters at the current position.
+func (p *selectorParser) scan() string {
+	start := p.pos
+	for p.pos < len(p.input) && isSelectorChar(p.input[p.pos]) {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+// consume advances past s if the input continues with it.
+func (p *selectorParser) consume(s string) bool {
+	if strings.HasPrefix(p.input[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+// skipSpace advances past any spaces.
+func (p *selectorParser) skipSpace() {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// errorf returns a *SelectorError at the current position.
+func (p *selectorParser) errorf(format string, args ...interface{}) error {
+	return &SelectorError{Selector: p.input, Pos: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// isSelectorChar reports whether c may be used in a label key or value.
+func isSelectorChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.' || c == '/'
+}
+
+// contains reports whether s holds v.
+func contains(s []string, v string) bool {
+	for _, elem := range s {
+		if elem == v {
+			return true
+		}
+	}
+	return false
+}
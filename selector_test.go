@@ -0,0 +1,107 @@
+package registrar
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSelector(t *testing.T) {
+	testCases := map[string]struct {
+		selector string
+		want     Selector
+		wantPos  int
+		wantErr  bool
+	}{
+		"empty":     {selector: "  ", want: nil},
+		"equals":    {selector: "vendor=dell", want: Selector{{Key: "vendor", Operator: SelectorEquals, Values: []string{"dell"}}}},
+		"double eq": {selector: "vendor == dell", want: Selector{{Key: "vendor", Operator: SelectorEquals, Values: []string{"dell"}}}},
+		"not equal": {selector: "vendor!=dell", want: Selector{{Key: "vendor", Operator: SelectorNotEquals, Values: []string{"dell"}}}},
+		"empty val": {selector: "vendor=", want: Selector{{Key: "vendor", Operator: SelectorEquals, Values: []string{""}}}},
+		"combined": {
+			selector: "vendor=dell,model in (r740, r640), firmware.family notin (idrac8),!deprecated,bmc.io/managed",
+			want: Selector{
+				{Key: "vendor", Operator: SelectorEquals, Values: []string{"dell"}},
+				{Key: "model", Operator: SelectorIn, Values: []string{"r640", "r740"}},
+				{Key: "firmware.family", Operator: SelectorNotIn, Values: []string{"idrac8"}},
+				{Key: "deprecated", Operator: SelectorDoesNotExist},
+				{Key: "bmc.io/managed", Operator: SelectorExists},
+			},
+		},
+		"unknown operator": {selector: "vendor like dell", wantErr: true, wantPos: 7},
+		"unclosed set":     {selector: "model in (r640", wantErr: true, wantPos: 14},
+		"missing set":      {selector: "model in r640", wantErr: true, wantPos: 9},
+		"empty set value":  {selector: "model in (r640,)", wantErr: true, wantPos: 15},
+		"missing key":      {selector: "vendor=dell,", wantErr: true, wantPos: 12},
+		"missing comma":    {selector: "vendor=dell model=r640", wantErr: true, wantPos: 12},
+		"bad character":    {selector: "vendor=dell;", wantErr: true, wantPos: 11},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseSelector(tc.selector)
+			if tc.wantErr {
+				var selErr *SelectorError
+				if !errors.As(err, &selErr) {
+					t.Fatalf("expected a *SelectorError, got %v", err)
+				}
+				if selErr.Pos != tc.wantPos {
+					t.Fatalf("expected error at position %d, got %d: %v", tc.wantPos, selErr.Pos, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Fatal(diff)
+			}
+			reparsed, err := ParseSelector(got.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(reparsed, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	rg := NewRegistry()
+	rg.Register("idrac9", "redfish", nil, nil, nil, WithLabels(map[string]string{"vendor": "dell", "model": "r640"}))
+	rg.Register("idrac8", "web", nil, nil, nil, WithLabels(map[string]string{"vendor": "dell", "model": "r730", "deprecated": "true"}))
+	rg.Register("x11", "redfish", nil, nil, nil, WithLabels(map[string]string{"vendor": "supermicro"}))
+	rg.Register("ipmitool", "ipmi", nil, nil, nil)
+
+	testCases := map[string]struct {
+		selector string
+		want     []string
+	}{
+		"everything":      {selector: "", want: []string{"idrac9", "idrac8", "x11", "ipmitool"}},
+		"vendor":          {selector: "vendor=dell", want: []string{"idrac9", "idrac8"}},
+		"not deprecated":  {selector: "vendor=dell,!deprecated", want: []string{"idrac9"}},
+		"in":              {selector: "model in (r640,r740)", want: []string{"idrac9"}},
+		"not in":          {selector: "model notin (r640)", want: []string{"idrac8", "x11", "ipmitool"}},
+		"not equal":       {selector: "vendor!=dell", want: []string{"x11", "ipmitool"}},
+		"exists":          {selector: "vendor", want: []string{"idrac9", "idrac8", "x11"}},
+		"nothing matches": {selector: "vendor=hpe"},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			got, err := rg.Select(tc.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got.Names(), tc.want); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+
+	if _, err := rg.Select("vendor in dell"); err == nil {
+		t.Fatal("expected an error for an invalid selector")
+	}
+}
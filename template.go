@@ -0,0 +1,88 @@
+package registrar
+
+import (
+	"sync"
+
+	"github.com/go-logr/logr"
+)
+
+// TargetConfigKey is the Config key under which Template.For stores the target.
+const TargetConfigKey = "target"
+
+// TemplateOption for setting optional Template values.
+type TemplateOption func(*Template)
+
+// TargetConfig returns the config specific to a target, for example its credentials.
+type TargetConfig func(target string) Config
+
+// Template holds driver factories that are stamped out into a fresh Registry per target,
+// for example once per BMC host, so registration code is written once.
+// All Template methods are safe for concurrent use.
+type Template struct {
+	mu           sync.RWMutex
+	logger       logr.Logger
+	config       Config
+	targetConfig TargetConfig
+	drivers      Drivers
+}
+
+// WithTemplateLogger sets the logger of the registries a Template creates.
+// Each registry logs with the target added as a "target" key value pair.
+func WithTemplateLogger(logger logr.Logger) TemplateOption {
+	return func(args *Template) { args.logger = logger }
+}
+
+// WithTemplateConfig sets the config shared by every target.
+func WithTemplateConfig(config Config) TemplateOption {
+	return func(args *Template) { args.config = config }
+}
+
+// WithTargetConfig sets the function returning the config of each target.
+// Its values override those of the shared config.
+func WithTargetConfig(fn TargetConfig) TemplateOption {
+	return func(args *Template) { args.targetConfig = fn }
+}
+
+// NewTemplate returns a new, empty, registry Template.
+func NewTemplate(opts ...TemplateOption) *Template {
+	t := &Template{logger: logr.Discard()}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// RegisterFactory will add a driver factory to the template, see Registry.RegisterFactory.
+func (t *Template) RegisterFactory(name, protocol string, features Features, metadata interface{}, factory Factory, opts ...DriverOption) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	d := newDriver(name, protocol, features, metadata, nil, opts...)
+	d.Factory = factory
+	t.drivers = append(t.drivers, d)
+}
+
+// For returns a new Registry holding the template's drivers, ready to be filtered and instantiated
+// for target. The registry Config is the shared config, the target under TargetConfigKey and the
+// config of the target, in increasing order of precedence. Changes to the returned registry
+// do not affect the template or the registries of other targets.
+func (t *Template) For(target string) *Registry {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	config := make(Config, len(t.config)+1)
+	for k, v := range t.config {
+		config[k] = v
+	}
+	config[TargetConfigKey] = target
+	if t.targetConfig != nil {
+		for k, v := range t.targetConfig(target) {
+			config[k] = v
+		}
+	}
+	drivers := make(Drivers, 0, len(t.drivers))
+	for _, elem := range t.drivers {
+		d := *elem
+		drivers = append(drivers, &d)
+	}
+
+	return NewRegistry(WithLogger(t.logger.WithValues("target", target)), WithConfig(config), WithDrivers(drivers))
+}
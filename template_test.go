@@ -0,0 +1,67 @@
+package registrar
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTemplateFor(t *testing.T) {
+	tmpl := NewTemplate(
+		WithTemplateConfig(Config{"user": "admin", "port": 443}),
+		WithTargetConfig(func(target string) Config {
+			if target == "10.0.0.2" {
+				return Config{"port": 8443}
+			}
+			return nil
+		}),
+	)
+	tmpl.RegisterFactory("gofish", "redfish", Features{FeaturePowerSet}, nil, func(_ context.Context, config Config) (interface{}, error) {
+		return config, nil
+	})
+	tmpl.RegisterFactory("ipmitool", "ipmi", Features{FeaturePowerSet}, nil, func(_ context.Context, config Config) (interface{}, error) {
+		return config, nil
+	})
+
+	tests := map[string]struct {
+		target string
+		want   Config
+	}{
+		"shared config": {target: "10.0.0.1", want: Config{"user": "admin", "port": 443, TargetConfigKey: "10.0.0.1"}},
+		"target config": {target: "10.0.0.2", want: Config{"user": "admin", "port": 8443, TargetConfigKey: "10.0.0.2"}},
+	}
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			rg := tmpl.For(tc.target)
+			drivers, err := rg.Instantiate(context.Background(), rg.Using("redfish"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(drivers.Names(), []string{"gofish"}); diff != "" {
+				t.Fatal(diff)
+			}
+			if diff := cmp.Diff(drivers[0].DriverInterface, tc.want); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestTemplateForIsolated(t *testing.T) {
+	tmpl := NewTemplate()
+	tmpl.RegisterFactory("gofish", "redfish", nil, nil, func(context.Context, Config) (interface{}, error) { return &driverOne{}, nil })
+
+	one := tmpl.For("10.0.0.1")
+	one.Drivers[0].Priority = 10
+	one.RegisterFactory("extra", "redfish", nil, nil, nil)
+
+	two := tmpl.For("10.0.0.2")
+	if diff := cmp.Diff(two.Snapshot().Names(), []string{"gofish"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if two.Drivers[0].Priority != 0 {
+		t.Fatal("expected changes to one target's drivers not to affect another's")
+	}
+}
@@ -0,0 +1,59 @@
+package registrar
+
+import "fmt"
+
+// Typed pairs a driver implementation asserted to T with its Driver descriptor.
+type Typed[T any] struct {
+	Driver *Driver
+	Impl   T
+}
+
+// Skipped describes a driver that was left out of a type assertion and why.
+type Skipped struct {
+	Driver *Driver
+	Reason string
+}
+
+// As returns, in order, the drivers whose DriverInterface implements T.
+// Every driver that does not implement T is returned in the skipped slice along with the reason.
+func As[T any](drivers Drivers) ([]Typed[T], []Skipped) {
+	var results []Typed[T]
+	var skipped []Skipped
+	for _, elem := range drivers {
+		if elem == nil {
+			continue
+		}
+		if elem.DriverInterface == nil && elem.Factory != nil {
+			skipped = append(skipped, Skipped{Driver: elem, Reason: "driver has not been instantiated"})
+			continue
+		}
+		if elem.DriverInterface == nil {
+			skipped = append(skipped, Skipped{Driver: elem, Reason: "driver interface is nil"})
+			continue
+		}
+		impl, ok := elem.DriverInterface.(T)
+		if !ok {
+			skipped = append(skipped, Skipped{Driver: elem, Reason: fmt.Sprintf("%T does not implement %s", elem.DriverInterface, typeName[T]())})
+			continue
+		}
+		results = append(results, Typed[T]{Driver: elem, Impl: impl})
+	}
+
+	return results, skipped
+}
+
+// Implementations returns, in order, just the implementations of T from the drivers.
+func Implementations[T any](drivers Drivers) []T {
+	typed, _ := As[T](drivers)
+	results := make([]T, 0, len(typed))
+	for _, elem := range typed {
+		results = append(results, elem.Impl)
+	}
+
+	return results
+}
+
+// typeName returns the name of the type T, which may be an interface type.
+func typeName[T any]() string {
+	return fmt.Sprintf("%T", (*T)(nil))[1:]
+}
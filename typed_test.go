@@ -0,0 +1,42 @@
+package registrar
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type thinger interface {
+	Thing() bool
+}
+
+type driverTwo struct{}
+
+func (driverTwo) Thing() bool { return true }
+
+func TestAs(t *testing.T) {
+	verifier := &driverOne{name: "one", isCompatible: true}
+	two := driverTwo{}
+	one := &Driver{Name: "one", Protocol: "tcp", DriverInterface: verifier}
+	second := &Driver{Name: "two", Protocol: "udp", DriverInterface: two}
+	empty := &Driver{Name: "empty", Protocol: "udp"}
+	drivers := Drivers{one, nil, second, empty}
+
+	typed, skipped := As[thinger](drivers)
+	if diff := cmp.Diff(typed, []Typed[thinger]{{Driver: second, Impl: two}}); diff != "" {
+		t.Fatal(diff)
+	}
+	want := []Skipped{
+		{Driver: one, Reason: "*registrar.driverOne does not implement registrar.thinger"},
+		{Driver: empty, Reason: "driver interface is nil"},
+	}
+	if diff := cmp.Diff(skipped, want, cmp.AllowUnexported(driverOne{})); diff != "" {
+		t.Fatal(diff)
+	}
+
+	verifiers := Implementations[Verifier](drivers)
+	if len(verifiers) != 1 || !verifiers[0].Compatible(context.Background()) {
+		t.Fatalf("expected a single compatible Verifier, got %v", verifiers)
+	}
+}
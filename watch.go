@@ -0,0 +1,237 @@
+package registrar
+
+import (
+	"context"
+	"sync"
+)
+
+// EventType is the kind of change an Event describes.
+type EventType int
+
+const (
+	// EventRegistered is emitted when a driver is added.
+	EventRegistered EventType = iota
+	// EventUnregistered is emitted when a driver is removed.
+	EventUnregistered
+	// EventReplaced is emitted when a driver is swapped for a new one, see Replace.
+	EventReplaced
+	// EventDisabled is emitted when a driver is disabled.
+	EventDisabled
+	// EventEnabled is emitted when a disabled driver is enabled again.
+	EventEnabled
+	// EventCompatibilityChanged is emitted when FilterForCompatible finds a driver's
+	// compatibility differs from the last time it was checked.
+	EventCompatibilityChanged
+)
+
+// String returns the name of the event type.
+func (e EventType) String() string {
+	switch e {
+	case EventRegistered:
+		return "registered"
+	case EventUnregistered:
+		return "unregistered"
+	case EventReplaced:
+		return "replaced"
+	case EventDisabled:
+		return "disabled"
+	case EventEnabled:
+		return "enabled"
+	case EventCompatibilityChanged:
+		return "compatibility-changed"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes a single change to the drivers of a Registry.
+type Event struct {
+	Type     EventType
+	Name     string
+	Protocol string
+	// Driver is the driver after the change. For EventUnregistered it is the removed driver.
+	Driver *Driver
+	// Compatible is the new compatibility of the driver, for EventCompatibilityChanged.
+	Compatible bool
+	// Err is the reason the driver is not compatible, for EventCompatibilityChanged.
+	Err error
+}
+
+// subscriber delivers events, in order, to a single callback from its own goroutine.
+// Events are queued without limit so changes to the registry never wait on a slow subscriber.
+type subscriber struct {
+	fn    func(Event)
+	mu    sync.Mutex
+	queue []Event
+	// wake is signaled when events are queued.
+	wake chan struct{}
+	stop chan struct{}
+	once sync.Once
+}
+
+// hiddenFunc reports whether a driver of an ancestor registry is overridden, and so hidden from a subscriber.
+type hiddenFunc func(name, protocol string) bool
+
+// Subscribe calls fn with every later change to the registry's drivers, in the order the changes
+// were made. Changes to the drivers of parent registries are included, except for drivers a layer
+// in between overrides. fn is called from a goroutine owned by the subscription, one event at a time,
+// so a slow fn delays only its own events. The returned function unsubscribes; events not yet
+// delivered are dropped. It does not wait for a call of fn in progress and may be called from fn.
+func (r *Registry) Subscribe(fn func(Event)) (unsubscribe func()) {
+	s := r.subscribe(fn, nil)
+	return func() { r.unsubscribe(s) }
+}
+
+// Watch returns a channel receiving every later change to the registry's drivers, in the order
+// the changes were made, see Subscribe. The subscription ends, and the channel is closed, when ctx is done.
+func (r *Registry) Watch(ctx context.Context) <-chan Event {
+	ch := make(chan Event)
+	s := r.subscribe(func(e Event) {
+		select {
+		case ch <- e:
+		case <-ctx.Done():
+		}
+	}, func() { close(ch) })
+	go func() {
+		<-ctx.Done()
+		r.unsubscribe(s)
+	}()
+	return ch
+}
+
+// subscribe adds a subscriber to the registry and every ancestor, and starts its delivery goroutine.
+// Ancestors queue their events directly, so events of every layer are ordered by when they were made.
+// done, when set, runs once delivery has stopped.
+func (r *Registry) subscribe(fn func(Event), done func()) *subscriber {
+	s := &subscriber{fn: fn, wake: make(chan struct{}, 1), stop: make(chan struct{})}
+	var below []*Registry
+	for layer := r; layer != nil; layer = layer.parent {
+		var hidden hiddenFunc
+		if len(below) > 0 {
+			overriding := make([]*Registry, len(below))
+			copy(overriding, below)
+			hidden = func(name, protocol string) bool {
+				for _, elem := range overriding {
+					if elem.overrides(name, protocol) {
+						return true
+					}
+				}
+				return false
+			}
+		}
+		layer.mu.Lock()
+		if layer.subscribers == nil {
+			layer.subscribers = make(map[*subscriber]hiddenFunc)
+		}
+		layer.subscribers[s] = hidden
+		layer.mu.Unlock()
+		below = append(below, layer)
+	}
+	go func() {
+		if done != nil {
+			defer done()
+		}
+		s.run()
+	}()
+	return s
+}
+
+// unsubscribe removes a subscriber from the registry and every ancestor and stops its delivery goroutine.
+func (r *Registry) unsubscribe(s *subscriber) {
+	for layer := r; layer != nil; layer = layer.parent {
+		layer.mu.Lock()
+		delete(layer.subscribers, s)
+		layer.mu.Unlock()
+	}
+	s.once.Do(func() { close(s.stop) })
+}
+
+// overrides reports whether the registry itself has a driver with the name and protocol,
+// hiding any inherited one.
+func (r *Registry) overrides(name, protocol string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, elem := range r.Drivers {
+		if isDriver(elem, name, protocol) {
+			return true
+		}
+	}
+	return false
+}
+
+// emit queues an event for every subscriber. It must be called with r.mu held for writing,
+// which is what orders the events.
+func (r *Registry) emit(typ EventType, reg *Driver) {
+	r.emitEvent(Event{Type: typ, Name: reg.Name, Protocol: reg.Protocol, Driver: reg})
+}
+
+// emitEvent queues e for every subscriber the driver is not hidden from. It must be called with r.mu held for writing.
+// Checking whether a descendant overrides the driver takes the descendant's lock, so locks are only
+// ever taken from parent to child.
+func (r *Registry) emitEvent(e Event) {
+	for s, hidden := range r.subscribers {
+		if hidden == nil || !hidden(e.Name, e.Protocol) {
+			s.push(e)
+		}
+	}
+}
+
+// recordCompatibility stores the outcome of a compatibility check and emits
+// EventCompatibilityChanged for every driver whose compatibility changed.
+func (r *Registry) recordCompatibility(report CompatibilityReport) {
+	if r.owner != nil {
+		r.owner.recordCompatibility(report)
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.compatible == nil {
+		r.compatible = make(map[driverKey]bool)
+	}
+	for _, res := range report {
+		key := driverKey{name: res.Name, protocol: res.Protocol}
+		if prev, ok := r.compatible[key]; ok && prev != res.Compatible {
+			r.emitEvent(Event{Type: EventCompatibilityChanged, Name: res.Name, Protocol: res.Protocol, Driver: res.Driver, Compatible: res.Compatible, Err: res.Err})
+		}
+		r.compatible[key] = res.Compatible
+	}
+}
+
+// push queues an event for delivery.
+func (s *subscriber) push(e Event) {
+	s.mu.Lock()
+	s.queue = append(s.queue, e)
+	s.mu.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run delivers queued events until the subscriber is stopped.
+func (s *subscriber) run() {
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-s.wake:
+		}
+		for {
+			s.mu.Lock()
+			batch := s.queue
+			s.queue = nil
+			s.mu.Unlock()
+			if len(batch) == 0 {
+				break
+			}
+			for _, e := range batch {
+				select {
+				case <-s.stop:
+					return
+				default:
+				}
+				s.fn(e)
+			}
+		}
+	}
+}
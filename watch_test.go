@@ -0,0 +1,176 @@
+package registrar
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// eventIDs returns a "type name/protocol" identifier for each event.
+func eventIDs(events []Event) []string {
+	ids := make([]string, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.Type.String()+" "+e.Name+"/"+e.Protocol)
+	}
+	return ids
+}
+
+// receive reads n events from ch, failing the test if they do not arrive in time.
+func receive(t *testing.T, ch <-chan Event, n int) []Event {
+	t.Helper()
+	var events []Event
+	for len(events) < n {
+		select {
+		case e := <-ch:
+			events = append(events, e)
+		case <-time.After(time.Second):
+			t.Fatalf("expected %d events, got %v", n, eventIDs(events))
+		}
+	}
+	return events
+}
+
+func TestSubscribe(t *testing.T) {
+	rg := NewRegistry()
+	rg.Register("existing", "tcp", nil, nil, &driverOne{})
+	ch := make(chan Event, 100)
+	unsubscribe := rg.Subscribe(func(e Event) { ch <- e })
+
+	rg.Register("one", "tcp", nil, nil, &driverOne{})
+	rg.RegisterFactory("lazy", "tcp", nil, nil, nil)
+	_ = rg.Disable("one", "tcp")
+	_ = rg.Disable("one", "tcp")
+	_ = rg.Enable("one", "tcp")
+	_ = rg.Replace("one", "tcp", nil, nil, &driverOne{})
+	_ = rg.Unregister("lazy", "tcp")
+	rg.SetDrivers(rg.Snapshot()[:1])
+
+	want := []string{
+		"registered one/tcp",
+		"registered lazy/tcp",
+		"disabled one/tcp",
+		"enabled one/tcp",
+		"replaced one/tcp",
+		"unregistered lazy/tcp",
+		"unregistered one/tcp",
+	}
+	if diff := cmp.Diff(eventIDs(receive(t, ch, len(want))), want); diff != "" {
+		t.Fatal(diff)
+	}
+
+	unsubscribe()
+	unsubscribe()
+	rg.Register("two", "tcp", nil, nil, &driverOne{})
+	select {
+	case e := <-ch:
+		t.Fatalf("expected no events after unsubscribing, got %v", eventIDs([]Event{e}))
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWatch(t *testing.T) {
+	rg := NewRegistry()
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := rg.Watch(ctx)
+
+	rg.Register("one", "tcp", nil, nil, &driverOne{})
+	if diff := cmp.Diff(eventIDs(receive(t, ch, 1)), []string{"registered one/tcp"}); diff != "" {
+		t.Fatal(diff)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Fatal("expected the channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the channel to be closed when the context is done")
+	}
+}
+
+func TestCompatibilityChangedEvent(t *testing.T) {
+	rg := NewRegistry()
+	flaky := &reasonDriver{compatible: true}
+	rg.Register("flaky", "ipmi", nil, nil, flaky)
+	rg.Register("steady", "redfish", nil, nil, &reasonDriver{compatible: true})
+	ch := make(chan Event, 100)
+	defer rg.Subscribe(func(e Event) { ch <- e })()
+
+	rg.FilterForCompatible(context.Background())
+	flaky.compatible, flaky.err = false, errNoIPMI
+	rg.FilterForCompatible(context.Background())
+	rg.FilterForCompatible(context.Background())
+	flaky.compatible, flaky.err = true, nil
+	rg.FilterForCompatible(context.Background())
+
+	events := receive(t, ch, 2)
+	if diff := cmp.Diff(eventIDs(events), []string{"compatibility-changed flaky/ipmi", "compatibility-changed flaky/ipmi"}); diff != "" {
+		t.Fatal(diff)
+	}
+	if events[0].Compatible || !errors.Is(events[0].Err, errNoIPMI) || !events[1].Compatible {
+		t.Fatalf("expected the events to carry the new compatibility, got %+v", events)
+	}
+	select {
+	case e := <-ch:
+		t.Fatalf("expected no more events, got %v", eventIDs([]Event{e}))
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestQueryCompatibilityChangedEvent(t *testing.T) {
+	rg := NewRegistry()
+	flaky := &reasonDriver{compatible: true}
+	rg.Register("flaky", "ipmi", nil, nil, flaky)
+	ch := make(chan Event, 100)
+	defer rg.Subscribe(func(e Event) { ch <- e })()
+
+	q := rg.Query().Using("ipmi").Compatible(context.Background())
+	q.Drivers()
+	flaky.compatible = false
+	q.Drivers()
+
+	if diff := cmp.Diff(eventIDs(receive(t, ch, 1)), []string{"compatibility-changed flaky/ipmi"}); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestSubscribeChild(t *testing.T) {
+	base := NewRegistry()
+	base.Register("gofish", "redfish", nil, nil, &driverOne{})
+	tenant := base.Child()
+	tenant.Register("gofish", "redfish", nil, nil, &driverOne{})
+	team := tenant.Child()
+	ch := make(chan Event, 100)
+	unsubscribe := team.Subscribe(func(e Event) { ch <- e })
+
+	base.Register("ipmitool", "ipmi", nil, nil, &driverOne{})
+	_ = base.Disable("gofish", "redfish")
+	tenant.Register("custom", "web", nil, nil, &driverOne{})
+
+	want := []string{"registered ipmitool/ipmi", "registered custom/web"}
+	if diff := cmp.Diff(eventIDs(receive(t, ch, len(want))), want); diff != "" {
+		t.Fatal(diff)
+	}
+	select {
+	case e := <-ch:
+		t.Fatalf("expected events for overridden drivers to be dropped, got %v", eventIDs([]Event{e}))
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unsubscribe()
+	base.Register("late", "ipmi", nil, nil, &driverOne{})
+	select {
+	case e := <-ch:
+		t.Fatalf("expected no events after unsubscribing, got %v", eventIDs([]Event{e}))
+	case <-time.After(50 * time.Millisecond):
+	}
+	base.mu.RLock()
+	defer base.mu.RUnlock()
+	if len(base.subscribers) != 0 {
+		t.Fatalf("expected the parent forwarding to be unsubscribed, got %d subscribers", len(base.subscribers))
+	}
+}